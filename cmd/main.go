@@ -1,22 +1,180 @@
 package main
 
 import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
 	"log"
 	"os"
+	"os/signal"
+	"slices"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/gpt-utils/internal/dto"
 
 	"github.com/gpt-utils/scripts"
+	"github.com/joho/godotenv"
+	"go.mongodb.org/mongo-driver/bson"
 )
 
 func main() {
+	if len(os.Args) < 2 {
+		usage(os.Stderr)
+		os.Exit(2)
+	}
+
+	name := os.Args[1]
+	if name == "help" || name == "-h" || name == "--help" {
+		usage(os.Stdout)
+		return
+	}
+
+	script, ok := scripts.Lookup(name)
+	if !ok {
+		fmt.Fprintf(os.Stderr, "script desconhecido: %s\n\n", name)
+		usage(os.Stderr)
+		os.Exit(2)
+	}
+
+	var (
+		filter         string
+		limit          = script.Limit
+		pageSize       = 100
+		dryRun         bool
+		reportDir      = "reports"
+		workers        = 1
+		matchThreshold = 0.75
+		titleLanguage  string
+		tagRank        = 60
+		resume         string
+	)
+
+	fs := flag.NewFlagSet(name, flag.ExitOnError)
+	// cada script registra só as flags que usa (Script.Flags)
+	flags := map[string]func(){
+		scripts.FlagFilter: func() {
+			fs.StringVar(&filter, scripts.FlagFilter, filter, "filtro Mongo em extended JSON (padrão do script se vazio)")
+		},
+		scripts.FlagLimit: func() {
+			fs.IntVar(&limit, scripts.FlagLimit, limit, "máximo de documentos processados (0 = sem limite)")
+		},
+		scripts.FlagPageSize: func() {
+			fs.IntVar(&pageSize, scripts.FlagPageSize, pageSize, "documentos buscados por página")
+		},
+		scripts.FlagDryRun: func() {
+			fs.BoolVar(&dryRun, scripts.FlagDryRun, dryRun, "não grava nada no banco nem no FTP")
+		},
+		scripts.FlagReportDir: func() {
+			fs.StringVar(&reportDir, scripts.FlagReportDir, reportDir, "diretório dos relatórios (ex.: diff do dry-run)")
+		},
+		scripts.FlagWorkers: func() {
+			fs.IntVar(&workers, scripts.FlagWorkers, workers, "documentos processados em paralelo")
+		},
+		scripts.FlagMatchThreshold: func() {
+			fs.Float64Var(&matchThreshold, scripts.FlagMatchThreshold, matchThreshold, "nota mínima (0 a 1) para aceitar um candidato da AniList")
+		},
+		scripts.FlagTitleLanguage: func() {
+			fs.StringVar(&titleLanguage, scripts.FlagTitleLanguage, titleLanguage, "ordem de idiomas do título de exibição, ex.: english,romaji (vazio mantém o título atual)")
+		},
+		scripts.FlagTagRank: func() {
+			fs.IntVar(&tagRank, scripts.FlagTagRank, tagRank, "rank mínimo (0 a 100) para uma tag da AniList aparecer em tags")
+		},
+		scripts.FlagResume: func() {
+			fs.StringVar(&resume, scripts.FlagResume, resume, "ID de uma execução em job_runs a continuar de onde parou")
+		},
+	}
+	for _, f := range script.Flags {
+		register, ok := flags[f]
+		if !ok {
+			log.Fatalf("script %s usa a flag desconhecida --%s", script.Name, f)
+		}
+		register()
+	}
+	logPath := fs.String("log", "server.log", "arquivo de log ('-' para stderr)")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "uso: %s %s [flags] %s\n\n%s\n\n", os.Args[0], script.Name, script.Usage, script.Description)
+		fs.PrintDefaults()
+	}
+	fs.Parse(os.Args[2:])
+
+	if *logPath != "-" {
+		logFile, err := os.OpenFile(*logPath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0666)
+		if err != nil {
+			log.Fatalf("Erro ao abrir arquivo de log: %v", err)
+		}
+		defer logFile.Close()
+
+		// Redireciona o log padrão para o arquivo
+		log.SetOutput(logFile)
+	}
 
-	logFile, err := os.OpenFile("server.log", os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0666)
+	opts := scripts.Options{
+		Script:         script.Name,
+		Filter:         script.Filter,
+		Limit:          limit,
+		PageSize:       pageSize,
+		DryRun:         dryRun,
+		Workers:        workers,
+		ReportDir:      reportDir,
+		Resume:         resume,
+		MatchThreshold: matchThreshold,
+		TagRank:        tagRank,
+		Args:           fs.Args(),
+	}
+	if filter != "" {
+		var m bson.M
+		if err := bson.UnmarshalExtJSON([]byte(filter), false, &m); err != nil {
+			fmt.Fprintf(os.Stderr, "--filter inválido: %v\n", err)
+			os.Exit(2)
+		}
+		opts.Filter = m
+	}
+	if opts.Filter == nil {
+		opts.Filter = bson.M{}
+	}
+	if titleLanguage != "" {
+		for _, language := range strings.Split(titleLanguage, ",") {
+			language = strings.TrimSpace(language)
+			if !slices.Contains(dto.TitleLanguages, language) {
+				fmt.Fprintf(os.Stderr, "--title-language inválido: %q (use %s)\n", language, strings.Join(dto.TitleLanguages, ", "))
+				os.Exit(2)
+			}
+			opts.TitlePreference = append(opts.TitlePreference, language)
+		}
+	}
+
+	if err := godotenv.Load(); err != nil {
+		log.Printf("arquivo .env não carregado: %v", err)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	cfg, err := scripts.ConfigFromEnv()
+	if err != nil {
+		log.Fatalf("Configuração inválida: %v", err)
+	}
+	runner, err := scripts.NewRunner(ctx, cfg)
 	if err != nil {
-		log.Fatalf("Erro ao abrir arquivo de log: %v", err)
+		log.Fatalf("Erro ao iniciar %s: %v", script.Name, err)
 	}
-	defer logFile.Close()
+	defer runner.Close(context.Background())
 
-	// Redireciona o log padrão para o arquivo
-	log.SetOutput(logFile)
+	if err := script.Run(ctx, runner, opts); err != nil {
+		log.Printf("%s falhou: %v", script.Name, err)
+		fmt.Fprintf(os.Stderr, "%s falhou: %v\n", script.Name, err)
+		os.Exit(1)
+	}
+}
 
-	scripts.UpdateJustTypeAnimes()
+func usage(w io.Writer) {
+	fmt.Fprintf(w, "uso: %s <script> [flags]\n\nScripts disponíveis:\n", os.Args[0])
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	for _, s := range scripts.Scripts() {
+		fmt.Fprintf(tw, "  %s\t%s\n", s.Name, s.Description)
+	}
+	tw.Flush()
+	fmt.Fprintf(w, "\nUse \"%s <script> --help\" para ver as flags de cada script.\n", os.Args[0])
 }
@@ -1,51 +1,255 @@
 package dto
 
 import (
+	"strings"
 	"time"
 
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
+// Anime é um documento da coleção animes. Todos os campos têm tag bson em
+// camelCase; documentos gravados antes das tags podem ter chaves em
+// minúsculas (ex.: anilistapi, e voiceactors nos personagens), que o script
+// migrate-bson-keys renomeia.
 type Anime struct {
 	ID                primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	AniListID         int                `bson:"aniListId,omitempty" json:"aniListId,omitempty"`
+	MalID             int                `bson:"malId,omitempty" json:"malId,omitempty"`
+	AniListMatch      *AniListMatch      `bson:"aniListMatch,omitempty" json:"aniListMatch,omitempty"`
 	Title             string             `bson:"title" json:"title"`
+	Titles            Titles             `bson:"titles" json:"titles"`
 	Status            string             `bson:"status" json:"status"`
-	StartDate         StartDate
-	EndDate           EndDate
-	Type              string `bson:"type" json:"type"`
-	Episodes          int    `bson:"episodes" json:"episodes"`
-	Format            string
-	Sources           []string    `bson:"sources" json:"sources"`
-	Characters        []Character `bson:"characters" json:"characters"`
-	Tags              []string    `bson:"tags" json:"tags"`
-	Synopsis          string      `bson:"synopsis" json:"synopsis"`
-	Synonyms          []string    `bson:"synonyms" json:"synonyms"`
-	PathImage         string      `bson:"pathImage" json:"pathImage"`
-	CreatedAt         time.Time   `bson:"createdAt" json:"createdAt"`
-	UpdatedAt         time.Time   `bson:"updatedAt" json:"updatedAt"`
-	Version           int         `bson:"__v" json:"__v"`
-	ChatGpt           bool
-	ChatGptDontFound  bool
-	AverageScore      int
-	CountryOfOrigin   string
-	Source            string
-	Duration          int
-	IsAdult           bool
-	AniListApi        bool
-	AniListNotFound   bool
-	StreamingEpisodes []StreamingEpisode
-	Studios           []Studio
-	Staffs            []Staff
+	StartDate         StartDate          `bson:"startDate" json:"startDate"`
+	EndDate           EndDate            `bson:"endDate" json:"endDate"`
+	Type              string             `bson:"type" json:"type"`
+	Episodes          int                `bson:"episodes" json:"episodes"`
+	Format            string             `bson:"format" json:"format"`
+	Sources           []string           `bson:"sources" json:"sources"`
+	Characters        []Character        `bson:"characters" json:"characters"`
+	Tags              []string           `bson:"tags" json:"tags"`
+	Genres            []string           `bson:"genres" json:"genres"`
+	AniListTags       []Tag              `bson:"aniListTags" json:"aniListTags"`
+	Synopsis          string             `bson:"synopsis" json:"synopsis"`
+	SynopsisMarkdown  string             `bson:"synopsisMarkdown,omitempty" json:"synopsisMarkdown,omitempty"`
+	SynopsisSpoilers  []string           `bson:"synopsisSpoilers,omitempty" json:"synopsisSpoilers,omitempty"`
+	SynopsisSource    string             `bson:"synopsisSource,omitempty" json:"synopsisSource,omitempty"`
+	Synonyms          []string           `bson:"synonyms" json:"synonyms"`
+	PathImage         string             `bson:"pathImage" json:"pathImage"`
+	BannerPathImage   string             `bson:"bannerPathImage,omitempty" json:"bannerPathImage,omitempty"`
+	CoverColor        string             `bson:"coverColor,omitempty" json:"coverColor,omitempty"`
+	CreatedAt         time.Time          `bson:"createdAt" json:"createdAt"`
+	UpdatedAt         time.Time          `bson:"updatedAt" json:"updatedAt"`
+	Version           int                `bson:"__v" json:"__v"`
+	ChatGpt           bool               `bson:"chatGpt" json:"chatGpt"`
+	ChatGptDontFound  bool               `bson:"chatGptDontFound" json:"chatGptDontFound"`
+	AverageScore      int                `bson:"averageScore" json:"averageScore"`
+	CountryOfOrigin   string             `bson:"countryOfOrigin" json:"countryOfOrigin"`
+	Source            string             `bson:"source" json:"source"`
+	Duration          int                `bson:"duration" json:"duration"`
+	IsAdult           bool               `bson:"isAdult" json:"isAdult"`
+	Hidden            bool               `bson:"hidden,omitempty" json:"hidden,omitempty"`
+	AniListApi        bool               `bson:"aniListApi" json:"aniListApi"`
+	AniListNotFound   bool               `bson:"aniListNotFound" json:"aniListNotFound"`
+	StreamingEpisodes []StreamingEpisode `bson:"streamingEpisodes" json:"streamingEpisodes"`
+	ExternalLinks     []ExternalLink     `bson:"externalLinks" json:"externalLinks"`
+	Trailer           *Trailer           `bson:"trailer,omitempty" json:"trailer,omitempty"`
+	Studios           []Studio           `bson:"studios" json:"studios"`
+	MainStudio        *Studio            `bson:"mainStudio,omitempty" json:"mainStudio,omitempty"`
+	Staffs            []Staff            `bson:"staffs" json:"staffs"`
+	NextAiringEpisode *AiringEpisode     `bson:"nextAiringEpisode" json:"nextAiringEpisode"`
+	AiringSchedule    []AiringEpisode    `bson:"airingSchedule" json:"airingSchedule"`
+	Relations         []Relation         `bson:"relations" json:"relations"`
+	Franchise         *FranchiseInfo     `bson:"franchise,omitempty" json:"franchise,omitempty"`
+}
+
+// AiringEpisode é um episódio ainda não exibido e quando ele vai ao ar.
+type AiringEpisode struct {
+	Episode  int       `bson:"episode" json:"episode"`
+	AiringAt time.Time `bson:"airingAt" json:"airingAt"`
+}
+
+// Titles são as variantes de título da AniList. Anime.Title guarda o
+// título de exibição escolhido entre elas.
+type Titles struct {
+	Romaji        string `bson:"romaji,omitempty" json:"romaji,omitempty"`
+	English       string `bson:"english,omitempty" json:"english,omitempty"`
+	Native        string `bson:"native,omitempty" json:"native,omitempty"`
+	UserPreferred string `bson:"userPreferred,omitempty" json:"userPreferred,omitempty"`
+}
+
+// TitleLanguages são os valores aceitos em Titles.Preferred.
+var TitleLanguages = []string{"romaji", "english", "native", "userPreferred"}
+
+// Get devolve a variante do idioma informado (um de TitleLanguages).
+func (t Titles) Get(language string) string {
+	switch language {
+	case "romaji":
+		return t.Romaji
+	case "english":
+		return t.English
+	case "native":
+		return t.Native
+	case "userPreferred":
+		return t.UserPreferred
+	}
+	return ""
+}
+
+// Preferred devolve a primeira variante não vazia na ordem de idiomas informada.
+func (t Titles) Preferred(order []string) string {
+	for _, language := range order {
+		if title := t.Get(language); title != "" {
+			return title
+		}
+	}
+	return ""
+}
+
+// All devolve as variantes não vazias, sem repetição.
+func (t Titles) All() []string {
+	return uniqueTitles(t.UserPreferred, t.Romaji, t.English, t.Native)
+}
+
+// TitleVariants devolve o título de exibição, as variantes da AniList e os
+// sinônimos, sem repetição, para busca e comparação.
+func (a Anime) TitleVariants() []string {
+	return uniqueTitles(append(append([]string{a.Title}, a.Titles.All()...), a.Synonyms...)...)
+}
+
+// uniqueTitles remove vazios e repetições (sem diferenciar maiúsculas), mantendo a ordem.
+func uniqueTitles(titles ...string) []string {
+	var out []string
+	for _, t := range titles {
+		t = strings.TrimSpace(t)
+		if t == "" {
+			continue
+		}
+		dup := false
+		for _, o := range out {
+			if strings.EqualFold(o, t) {
+				dup = true
+				break
+			}
+		}
+		if !dup {
+			out = append(out, t)
+		}
+	}
+	return out
+}
+
+// MergeSynonyms junta os sinônimos sem repetição, deixando de fora o
+// título de exibição.
+func MergeSynonyms(title string, synonyms ...[]string) []string {
+	var all []string
+	for _, s := range synonyms {
+		all = append(all, s...)
+	}
+	merged := []string{}
+	for _, s := range uniqueTitles(all...) {
+		if !strings.EqualFold(s, strings.TrimSpace(title)) {
+			merged = append(merged, s)
+		}
+	}
+	return merged
+}
+
+// Tag é uma tag da AniList. Rank (0 a 100) indica o quanto a tag se aplica
+// à mídia; tags com IsSpoiler revelam enredo e não devem aparecer na busca.
+type Tag struct {
+	Name      string `bson:"name" json:"name"`
+	Rank      int    `bson:"rank" json:"rank"`
+	IsSpoiler bool   `bson:"isSpoiler" json:"isSpoiler"`
+	Category  string `bson:"category" json:"category"`
+}
+
+// Relation é uma relação AniList com outra mídia. AnimeID (ou MangaID,
+// conforme MediaType) aponta para o nosso documento quando a mídia
+// relacionada existe no catálogo.
+type Relation struct {
+	Type      string              `bson:"type" json:"type"`
+	AniListID int                 `bson:"aniListId" json:"aniListId"`
+	MediaType string              `bson:"mediaType" json:"mediaType"`
+	Format    string              `bson:"format" json:"format"`
+	Title     string              `bson:"title" json:"title"`
+	StartDate StartDate           `bson:"startDate" json:"startDate"`
+	AnimeID   *primitive.ObjectID `bson:"animeId,omitempty" json:"animeId,omitempty"`
+	MangaID   *primitive.ObjectID `bson:"mangaId,omitempty" json:"mangaId,omitempty"`
+}
+
+// FranchiseInfo é calculado pelo comando franchise: ID identifica o grupo
+// (AniList ID do primeiro título da ordem) e Order é a posição na ordem de
+// exibição sugerida, começando em 1.
+type FranchiseInfo struct {
+	ID    int `bson:"id" json:"id"`
+	Order int `bson:"order" json:"order"`
+	Size  int `bson:"size" json:"size"`
+}
+
+// AniListMatch registra qual candidato da AniList foi escolhido para o
+// anime e com que nota; Accepted é false quando a nota ficou abaixo do
+// limite e o anime aguarda revisão.
+type AniListMatch struct {
+	AniListID int       `bson:"aniListId" json:"aniListId"`
+	Title     string    `bson:"title" json:"title"`
+	Score     float64   `bson:"score" json:"score"`
+	Accepted  bool      `bson:"accepted" json:"accepted"`
+	MatchedAt time.Time `bson:"matchedAt" json:"matchedAt"`
 }
 
 type Staff struct {
 	ID        primitive.ObjectID
+	AniListID int                 `bson:"aniListId,omitempty" json:"aniListId,omitempty"`
+	PersonID  *primitive.ObjectID `bson:"personId,omitempty" json:"personId,omitempty"`
 	Name      string
 	SiteUrl   string
 	PathImage string
 	HomeTown  string
 	Gender    string
 	Age       int
+	// Roles são os papéis da pessoa no anime como vêm da AniList,
+	// ex.: "Director", "Key Animation (OP)".
+	Roles []string `bson:"roles" json:"roles"`
+}
+
+// KeyStaffRoles são os papéis exibidos em destaque na página do anime.
+var KeyStaffRoles = []string{"Director", "Original Creator", "Music", "Character Design"}
+
+// KeyRoles devolve os papéis de KeyStaffRoles que a pessoa exerce,
+// ignorando detalhes entre parênteses ("Music (OP)" conta como "Music").
+func (s Staff) KeyRoles() []string {
+	var roles []string
+	for _, role := range s.Roles {
+		if i := strings.Index(role, "("); i >= 0 {
+			role = strings.TrimSpace(role[:i])
+		}
+		for _, key := range KeyStaffRoles {
+			if role == key && !containsString(roles, key) {
+				roles = append(roles, key)
+			}
+		}
+	}
+	return roles
+}
+
+// KeyStaff filtra a staff deixando só quem tem algum papel de KeyStaffRoles.
+func KeyStaff(staffs []Staff) []Staff {
+	var key []Staff
+	for _, s := range staffs {
+		if len(s.KeyRoles()) > 0 {
+			key = append(key, s)
+		}
+	}
+	return key
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
 }
 
 type StartDate struct {
@@ -73,19 +277,26 @@ type DateOfBirth struct {
 
 type Character struct {
 	ID          primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	AniListID   int                `bson:"aniListId,omitempty" json:"aniListId,omitempty"`
 	Name        string             `bson:"name" json:"name"`
+	NativeName  string             `bson:"nativeName,omitempty" json:"nativeName,omitempty"`
 	PathImage   string             `bson:"pathImage" json:"pathImage"`
 	Link        string             `bson:"link" json:"link"`
 	Bio         string             `bson:"bio" json:"bio"`
+	BioMarkdown string             `bson:"bioMarkdown,omitempty" json:"bioMarkdown,omitempty"`
+	BioSpoilers []string           `bson:"bioSpoilers,omitempty" json:"bioSpoilers,omitempty"`
+	BioSource   string             `bson:"bioSource,omitempty" json:"bioSource,omitempty"`
 	Tags        []string           `bson:"tags" json:"tags"`
-	Age         string
-	DateOfBirth DateOfBirth
-	AniListApi  bool
-	VoiceActors []VoiceActor
+	Age         string             `bson:"age" json:"age"`
+	DateOfBirth DateOfBirth        `bson:"dateOfBirth" json:"dateOfBirth"`
+	AniListApi  bool               `bson:"aniListApi" json:"aniListApi"`
+	VoiceActors []VoiceActor       `bson:"voiceActors" json:"voiceActors"`
 }
 
 type VoiceActor struct {
 	ID          primitive.ObjectID
+	AniListID   int                 `bson:"aniListId,omitempty" json:"aniListId,omitempty"`
+	PersonID    *primitive.ObjectID `bson:"personId,omitempty" json:"personId,omitempty"`
 	Name        string
 	Image       string
 	LanguageV2  string
@@ -102,9 +313,13 @@ type VoiceActor struct {
 }
 
 type Studio struct {
-	ID      primitive.ObjectID
-	Name    string
-	SiteUrl string
+	ID                primitive.ObjectID
+	AniListID         int                 `bson:"aniListId,omitempty" json:"aniListId,omitempty"`
+	StudioID          *primitive.ObjectID `bson:"studioId,omitempty" json:"studioId,omitempty"`
+	Name              string
+	SiteUrl           string
+	IsAnimationStudio bool `bson:"isAnimationStudio" json:"isAnimationStudio"`
+	IsMain            bool `bson:"isMain" json:"isMain"`
 }
 
 type StreamingEpisode struct {
@@ -112,4 +327,32 @@ type StreamingEpisode struct {
 	Site      string
 	Title     string
 	PathImage string
+	Url       string `bson:"url" json:"url"`
+}
+
+// ExternalLink é um link oficial da AniList. Type é STREAMING, INFO ou
+// SOCIAL; Language vem preenchido em sites de streaming regionais.
+type ExternalLink struct {
+	Site     string `bson:"site" json:"site"`
+	Url      string `bson:"url" json:"url"`
+	Type     string `bson:"type" json:"type"`
+	Language string `bson:"language,omitempty" json:"language,omitempty"`
+}
+
+// Trailer é o trailer oficial; ID é o ID do vídeo no Site (youtube ou dailymotion).
+type Trailer struct {
+	ID        string `bson:"id" json:"id"`
+	Site      string `bson:"site" json:"site"`
+	Thumbnail string `bson:"thumbnail" json:"thumbnail"`
+}
+
+// URL monta o link do trailer no site de origem.
+func (t Trailer) URL() string {
+	switch t.Site {
+	case "youtube":
+		return "https://www.youtube.com/watch?v=" + t.ID
+	case "dailymotion":
+		return "https://www.dailymotion.com/video/" + t.ID
+	}
+	return ""
 }
@@ -0,0 +1,52 @@
+package dto
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	JobRunRunning  = "running"
+	JobRunStopped  = "stopped"
+	JobRunFailed   = "failed"
+	JobRunFinished = "finished"
+
+	OutcomeSuccess  = "success"
+	OutcomeFailure  = "failure"
+	OutcomeNotFound = "notFound"
+)
+
+// JobRun é o checkpoint de uma execução de script, salvo em job_runs.
+// Filter é guardado em extended JSON porque operadores como $ne não
+// podem ser chaves de um documento salvo.
+type JobRun struct {
+	ID         string             `bson:"_id" json:"id"`
+	Script     string             `bson:"script" json:"script"`
+	Filter     string             `bson:"filter" json:"filter"`
+	LastID     primitive.ObjectID `bson:"lastId,omitempty" json:"lastId"`
+	Status     string             `bson:"status" json:"status"`
+	Error      string             `bson:"error,omitempty" json:"error,omitempty"`
+	Processed  int                `bson:"processed" json:"processed"`
+	Counts     JobRunCounts       `bson:"counts" json:"counts"`
+	Outcomes   []JobOutcome       `bson:"outcomes" json:"outcomes"`
+	StartedAt  time.Time          `bson:"startedAt" json:"startedAt"`
+	UpdatedAt  time.Time          `bson:"updatedAt" json:"updatedAt"`
+	FinishedAt *time.Time         `bson:"finishedAt,omitempty" json:"finishedAt,omitempty"`
+}
+
+type JobRunCounts struct {
+	Success  int `bson:"success" json:"success"`
+	Failure  int `bson:"failure" json:"failure"`
+	NotFound int `bson:"notFound" json:"notFound"`
+}
+
+// JobOutcome é o resultado de um documento. Só falhas e não encontrados
+// são guardados individualmente; sucessos entram apenas em Counts.
+type JobOutcome struct {
+	AnimeID primitive.ObjectID `bson:"animeId" json:"animeId"`
+	Title   string             `bson:"title" json:"title"`
+	Outcome string             `bson:"outcome" json:"outcome"`
+	Error   string             `bson:"error,omitempty" json:"error,omitempty"`
+	At      time.Time          `bson:"at" json:"at"`
+}
@@ -0,0 +1,49 @@
+package dto
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Manga é um documento da coleção mangas. Os campos seguem os de Anime;
+// AnimeIDs são as adaptações em anime que já existem no catálogo.
+// Serialization (a revista de publicação) não existe na AniList e só é
+// preenchido manualmente.
+type Manga struct {
+	ID               primitive.ObjectID   `bson:"_id,omitempty" json:"id"`
+	AniListID        int                  `bson:"aniListId,omitempty" json:"aniListId,omitempty"`
+	MalID            int                  `bson:"malId,omitempty" json:"malId,omitempty"`
+	Title            string               `bson:"title" json:"title"`
+	Titles           Titles               `bson:"titles" json:"titles"`
+	Synonyms         []string             `bson:"synonyms" json:"synonyms"`
+	Status           string               `bson:"status" json:"status"`
+	Format           string               `bson:"format" json:"format"`
+	StartDate        StartDate            `bson:"startDate" json:"startDate"`
+	EndDate          EndDate              `bson:"endDate" json:"endDate"`
+	Chapters         int                  `bson:"chapters" json:"chapters"`
+	Volumes          int                  `bson:"volumes" json:"volumes"`
+	Serialization    string               `bson:"serialization,omitempty" json:"serialization,omitempty"`
+	Synopsis         string               `bson:"synopsis" json:"synopsis"`
+	SynopsisMarkdown string               `bson:"synopsisMarkdown,omitempty" json:"synopsisMarkdown,omitempty"`
+	SynopsisSpoilers []string             `bson:"synopsisSpoilers,omitempty" json:"synopsisSpoilers,omitempty"`
+	SynopsisSource   string               `bson:"synopsisSource,omitempty" json:"synopsisSource,omitempty"`
+	Genres           []string             `bson:"genres" json:"genres"`
+	Tags             []string             `bson:"tags" json:"tags"`
+	AniListTags      []Tag                `bson:"aniListTags" json:"aniListTags"`
+	AverageScore     int                  `bson:"averageScore" json:"averageScore"`
+	CountryOfOrigin  string               `bson:"countryOfOrigin" json:"countryOfOrigin"`
+	Source           string               `bson:"source" json:"source"`
+	IsAdult          bool                 `bson:"isAdult" json:"isAdult"`
+	Hidden           bool                 `bson:"hidden,omitempty" json:"hidden,omitempty"`
+	PathImage        string               `bson:"pathImage" json:"pathImage"`
+	CoverColor       string               `bson:"coverColor,omitempty" json:"coverColor,omitempty"`
+	Staffs           []Staff              `bson:"staffs" json:"staffs"`
+	Relations        []Relation           `bson:"relations" json:"relations"`
+	AnimeIDs         []primitive.ObjectID `bson:"animeIds" json:"animeIds"`
+	AniListApi       bool                 `bson:"aniListApi" json:"aniListApi"`
+	AniListNotFound  bool                 `bson:"aniListNotFound" json:"aniListNotFound"`
+	AniListMatch     *AniListMatch        `bson:"aniListMatch,omitempty" json:"aniListMatch,omitempty"`
+	CreatedAt        time.Time            `bson:"createdAt" json:"createdAt"`
+	UpdatedAt        time.Time            `bson:"updatedAt" json:"updatedAt"`
+}
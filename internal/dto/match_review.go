@@ -0,0 +1,47 @@
+package dto
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	ReviewPending  = "pending"
+	ReviewAccepted = "accepted"
+	ReviewRejected = "rejected"
+
+	ReviewReasonLowConfidence = "lowConfidence"
+	ReviewReasonNotFound      = "notFound"
+)
+
+// MatchReview é um anime cujo casamento com a AniList precisa de revisão
+// manual, salvo em match_reviews junto com os melhores candidatos.
+type MatchReview struct {
+	ID         primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	AnimeID    primitive.ObjectID `bson:"animeId" json:"animeId"`
+	Title      string             `bson:"title" json:"title"`
+	Synonyms   []string           `bson:"synonyms" json:"synonyms"`
+	Format     string             `bson:"format" json:"format"`
+	Episodes   int                `bson:"episodes" json:"episodes"`
+	StartYear  int                `bson:"startYear" json:"startYear"`
+	Reason     string             `bson:"reason" json:"reason"`
+	Candidates []ReviewCandidate  `bson:"candidates" json:"candidates"`
+	Status     string             `bson:"status" json:"status"`
+	AcceptedID int                `bson:"acceptedId,omitempty" json:"acceptedId,omitempty"`
+	CreatedAt  time.Time          `bson:"createdAt" json:"createdAt"`
+	ResolvedAt *time.Time         `bson:"resolvedAt,omitempty" json:"resolvedAt,omitempty"`
+}
+
+type ReviewCandidate struct {
+	AniListID int      `bson:"aniListId" json:"aniListId"`
+	MalID     int      `bson:"malId" json:"malId"`
+	Romaji    string   `bson:"romaji" json:"romaji"`
+	English   string   `bson:"english" json:"english"`
+	Native    string   `bson:"native" json:"native"`
+	Synonyms  []string `bson:"synonyms" json:"synonyms"`
+	Format    string   `bson:"format" json:"format"`
+	Episodes  int      `bson:"episodes" json:"episodes"`
+	StartYear int      `bson:"startYear" json:"startYear"`
+	Score     float64  `bson:"score" json:"score"`
+}
@@ -0,0 +1,41 @@
+package dto
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Person é uma pessoa da AniList (dublador ou staff) na coleção people,
+// única por AniListID. Staff e VoiceActor nos animes apontam para ela por PersonID.
+type Person struct {
+	ID          primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	AniListID   int                `bson:"aniListId" json:"aniListId"`
+	Name        string             `bson:"name" json:"name"`
+	NativeName  string             `bson:"nativeName,omitempty" json:"nativeName,omitempty"`
+	Image       string             `bson:"image" json:"image"`
+	SiteUrl     string             `bson:"siteUrl" json:"siteUrl"`
+	HomeTown    string             `bson:"homeTown" json:"homeTown"`
+	Gender      string             `bson:"gender" json:"gender"`
+	Age         int                `bson:"age" json:"age"`
+	LanguageV2  string             `bson:"languageV2,omitempty" json:"languageV2,omitempty"`
+	DateOfBirth DateOfBirth        `bson:"dateOfBirth" json:"dateOfBirth"`
+	DateOfDeath DateOfBirth        `bson:"dateOfDeath" json:"dateOfDeath"`
+	CreatedAt   time.Time          `bson:"createdAt" json:"createdAt"`
+	UpdatedAt   time.Time          `bson:"updatedAt" json:"updatedAt"`
+}
+
+const (
+	PersonRoleStaff = "staff"
+	PersonRoleVoice = "voice"
+)
+
+// PersonRole é uma participação da pessoa em um anime do catálogo: um papel
+// de staff ou a dublagem de um personagem.
+type PersonRole struct {
+	AnimeID    primitive.ObjectID `json:"animeId"`
+	AnimeTitle string             `json:"animeTitle"`
+	Kind       string             `json:"kind"`
+	Role       string             `json:"role"`
+	Character  string             `json:"character,omitempty"`
+}
@@ -0,0 +1,63 @@
+package dto
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// StatsSnapshot é uma leitura das estatísticas da AniList de um anime,
+// gravada a cada enriquecimento na coleção de série temporal anime_stats.
+type StatsSnapshot struct {
+	ID           primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	AnimeID      primitive.ObjectID `bson:"animeId" json:"animeId"`
+	AniListID    int                `bson:"aniListId" json:"aniListId"`
+	Title        string             `bson:"title" json:"title"`
+	TakenAt      time.Time          `bson:"takenAt" json:"takenAt"`
+	AverageScore int                `bson:"averageScore" json:"averageScore"`
+	MeanScore    int                `bson:"meanScore" json:"meanScore"`
+	Popularity   int                `bson:"popularity" json:"popularity"`
+	Favourites   int                `bson:"favourites" json:"favourites"`
+	Trending     int                `bson:"trending" json:"trending"`
+	Rankings     []Ranking          `bson:"rankings" json:"rankings"`
+}
+
+// Ranking é uma posição do anime nos rankings da AniList, como
+// "#12 most popular all time" (Type POPULAR, AllTime true).
+type Ranking struct {
+	Rank    int    `bson:"rank" json:"rank"`
+	Type    string `bson:"type" json:"type"`
+	Context string `bson:"context" json:"context"`
+	AllTime bool   `bson:"allTime" json:"allTime"`
+	Season  string `bson:"season,omitempty" json:"season,omitempty"`
+	Year    int    `bson:"year,omitempty" json:"year,omitempty"`
+}
+
+// StatsMetrics são os campos de StatsSnapshot comparáveis no relatório de movers.
+var StatsMetrics = []string{"popularity", "favourites", "trending", "averageScore", "meanScore"}
+
+// Metric devolve o valor do campo informado (um de StatsMetrics).
+func (s StatsSnapshot) Metric(name string) int {
+	switch name {
+	case "popularity":
+		return s.Popularity
+	case "favourites":
+		return s.Favourites
+	case "trending":
+		return s.Trending
+	case "averageScore":
+		return s.AverageScore
+	case "meanScore":
+		return s.MeanScore
+	}
+	return 0
+}
+
+// StatsMover é a variação de uma métrica de um anime entre duas datas.
+type StatsMover struct {
+	AnimeID primitive.ObjectID `json:"animeId"`
+	Title   string             `json:"title"`
+	From    int                `json:"from"`
+	To      int                `json:"to"`
+	Delta   int                `json:"delta"`
+}
@@ -0,0 +1,19 @@
+package dto
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// StudioDocument é um estúdio ou empresa da AniList na coleção studios,
+// único por AniListID. Os Studio dentro dos animes apontam para ele por StudioID.
+type StudioDocument struct {
+	ID                primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	AniListID         int                `bson:"aniListId" json:"aniListId"`
+	Name              string             `bson:"name" json:"name"`
+	SiteUrl           string             `bson:"siteUrl" json:"siteUrl"`
+	IsAnimationStudio bool               `bson:"isAnimationStudio" json:"isAnimationStudio"`
+	CreatedAt         time.Time          `bson:"createdAt" json:"createdAt"`
+	UpdatedAt         time.Time          `bson:"updatedAt" json:"updatedAt"`
+}
@@ -1,99 +1,299 @@
 package logic
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"time"
 
 	"github.com/gpt-utils/internal/dto"
 )
 
-const anilistURL = "https://graphql.anilist.co"
+const (
+	anilistURL = "https://graphql.anilist.co"
 
-// ...existing code...
-type ResponseAnilist struct {
-	Data struct {
-		Media struct {
-			ID      int `json:"id"`
-			Title   Title
-			Studios struct {
-				Nodes []struct {
-					Name    string
-					SiteUrl string
+	// aniListMaxAttempts é o número de tentativas de uma requisição com erro transitório.
+	aniListMaxAttempts = 5
+)
+
+// AniListClient faz as consultas GraphQL na AniList. URL pode apontar
+// para um servidor fake em testes. Um mesmo client deve ser compartilhado
+// entre os workers para que o Limiter valha para todas as requisições.
+type AniListClient struct {
+	HTTP    *http.Client
+	URL     string
+	Limiter *RateLimiter
+	// Adult é a política para mídias adultas; vazio equivale a AdultExclude.
+	Adult AdultPolicy
+}
+
+func NewAniListClient(httpClient *http.Client) *AniListClient {
+	return &AniListClient{
+		HTTP:    httpClient,
+		URL:     anilistURL,
+		Limiter: NewRateLimiter(),
+	}
+}
+
+// post envia o corpo GraphQL respeitando o Limiter. Erros transitórios
+// (falha de rede, 429 e 5xx) são repetidos até aniListMaxAttempts vezes;
+// os demais voltam como *GraphQLError.
+func (c *AniListClient) post(ctx context.Context, body interface{}, headers map[string]string) ([]byte, error) {
+	jsonData, err := json.Marshal(body)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao codificar JSON: %w", err)
+	}
+
+	var lastErr error
+	for attempt := 1; attempt <= aniListMaxAttempts; attempt++ {
+		if attempt > 1 {
+			log.Printf("AniList: %v (tentativa %d de %d)", lastErr, attempt, aniListMaxAttempts)
+		}
+		if err := c.Limiter.Wait(ctx); err != nil {
+			return nil, err
+		}
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.URL, bytes.NewReader(jsonData))
+		if err != nil {
+			return nil, fmt.Errorf("erro ao criar requisição: %w", err)
+		}
+		for key, value := range headers {
+			req.Header.Set(key, value)
+		}
+
+		resp, err := c.HTTP.Do(req)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			lastErr = fmt.Errorf("erro ao enviar requisição: %w", err)
+			if err := sleepContext(ctx, backoff(attempt)); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		data, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+
+		retryAfter := c.Limiter.Observe(resp)
+		if err != nil {
+			lastErr = fmt.Errorf("erro ao ler resposta: %w", err)
+			// como nos erros temporários: o Limiter já segura pelo Retry-After, se veio
+			if retryAfter == 0 {
+				if err := sleepContext(ctx, backoff(attempt)); err != nil {
+					return nil, err
 				}
 			}
-			Type  string
-			Staff struct {
-				Edges []struct {
-					Node struct {
-						ID    int
-						Age   int
-						Image struct {
-							Large string
-						}
-						Gender   string
-						HomeTown string
-						Name     struct {
-							Full   string
-							Native string
-						}
-						SiteUrl string
-					}
+			continue
+		}
+
+		err = checkGraphQLResponse(resp.StatusCode, data)
+		var gqlErr *GraphQLError
+		if errors.As(err, &gqlErr) && gqlErr.Temporary() {
+			lastErr = err
+			// no 429 o Limiter já segura a próxima requisição pelo Retry-After
+			if retryAfter == 0 {
+				if err := sleepContext(ctx, backoff(attempt)); err != nil {
+					return nil, err
 				}
 			}
-			Description       string `json:"description"`
-			AverageScore      int    `json:"averageScore"`
-			CountryOfOrigin   string `json:"countryOfOrigin"`
-			Source            string
-			Duration          int
-			Episodes          int    `json:"episodes"`
-			Format            string `json:"type"`
-			StreamingEpisodes []StreamingEpisode
-			StartDate         struct {
-				Day   int `json:"day"`
-				Month int `json:"month"`
-				Year  int `json:"year"`
-			} `json:"startDate"`
-			EndDate struct {
-				Day   int `json:"day"`
-				Month int `json:"month"`
-				Year  int `json:"year"`
-			} `json:"endDate"`
-			Status     string
-			IsAdult    bool     `json:"isAdult"`
-			Synonyms   []string `json:"synonyms"`
-			Characters struct {
-				PageInfo struct {
-					CurrentPage int  `json:"currentPage"`
-					LastPage    int  `json:"lastPage"`
-					PerPage     int  `json:"perPage"`
-					HasNextPage bool `json:"hasNextPage"`
-				} `json:"pageInfo"`
-				Edges []struct {
-					Role        string `json:"role"`
-					VoiceActors []voiceActor
-					Node        struct {
-						DateOfBirth struct {
-							Day   int `json:"day"`
-							Month int `json:"month"`
-							Year  int `json:"year"`
-						} `json:"dateOfBirth"`
-						Age  string `json:"age"`
-						ID   int    `json:"id"`
-						Name struct {
-							Full   string `json:"full"`
-							Native string `json:"native"`
-						} `json:"name"`
-						Image struct {
-							Large  string `json:"large"`
-							Medium string `json:"medium"`
-						} `json:"image"`
-						Description string `json:"description"`
-						SiteURL     string `json:"siteUrl"`
-					} `json:"node"`
-				} `json:"edges"`
-			} `json:"characters"`
-		} `json:"Media"`
-	} `json:"data"`
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		return data, nil
+	}
+	return nil, lastErr
+}
+
+// backoff é a espera antes da próxima tentativa; é uma variável para que
+// os testes não precisem esperar.
+var backoff = func(attempt int) time.Duration {
+	return time.Duration(1<<(attempt-1)) * time.Second
+}
+
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// ResponseAnilist é a resposta da consulta graphql/media.graphql.
+type ResponseAnilist struct {
+	Data mediaData[MediaDetail] `json:"data"`
+}
+
+// MediaDetail são os campos de uma mídia buscados pelo enrich-anilist e pelo enrich-manga.
+type MediaDetail struct {
+	ID      int `json:"id"`
+	IDMal   int `json:"idMal"`
+	Title   Title
+	Studios struct {
+		Edges []StudioEdge `json:"edges"`
+	}
+	Type  string
+	Staff struct {
+		PageInfo struct {
+			HasNextPage bool `json:"hasNextPage"`
+		} `json:"pageInfo"`
+		Edges []StaffEdge
+	}
+	Description       string `json:"description"`
+	AverageScore      int    `json:"averageScore"`
+	CountryOfOrigin   string `json:"countryOfOrigin"`
+	Source            string
+	Duration          int
+	Episodes          int    `json:"episodes"`
+	Format            string `json:"format"`
+	Chapters          int    `json:"chapters"`
+	Volumes           int    `json:"volumes"`
+	StreamingEpisodes []StreamingEpisode
+	StartDate         struct {
+		Day   int `json:"day"`
+		Month int `json:"month"`
+		Year  int `json:"year"`
+	} `json:"startDate"`
+	EndDate struct {
+		Day   int `json:"day"`
+		Month int `json:"month"`
+		Year  int `json:"year"`
+	} `json:"endDate"`
+	Status            string
+	NextAiringEpisode *AiringEpisode `json:"nextAiringEpisode"`
+	AiringSchedule    struct {
+		Nodes []AiringEpisode `json:"nodes"`
+	} `json:"airingSchedule"`
+	Relations struct {
+		Edges []RelationEdge `json:"edges"`
+	} `json:"relations"`
+	CoverImage struct {
+		ExtraLarge string `json:"extraLarge"`
+		Large      string `json:"large"`
+		Color      string `json:"color"`
+	} `json:"coverImage"`
+	BannerImage   string `json:"bannerImage"`
+	ExternalLinks []struct {
+		Site     string `json:"site"`
+		Url      string `json:"url"`
+		Type     string `json:"type"`
+		Language string `json:"language"`
+	} `json:"externalLinks"`
+	Trailer *struct {
+		ID        string `json:"id"`
+		Site      string `json:"site"`
+		Thumbnail string `json:"thumbnail"`
+	} `json:"trailer"`
+	MeanScore  int `json:"meanScore"`
+	Popularity int `json:"popularity"`
+	Favourites int `json:"favourites"`
+	Trending   int `json:"trending"`
+	Rankings   []struct {
+		Rank    int    `json:"rank"`
+		Type    string `json:"type"`
+		Context string `json:"context"`
+		AllTime bool   `json:"allTime"`
+		Season  string `json:"season"`
+		Year    int    `json:"year"`
+	} `json:"rankings"`
+	IsAdult  bool     `json:"isAdult"`
+	Synonyms []string `json:"synonyms"`
+	Genres   []string `json:"genres"`
+	Tags     []struct {
+		Name           string `json:"name"`
+		Rank           int    `json:"rank"`
+		IsMediaSpoiler bool   `json:"isMediaSpoiler"`
+		Category       string `json:"category"`
+	} `json:"tags"`
+	Characters struct {
+		PageInfo struct {
+			CurrentPage int  `json:"currentPage"`
+			LastPage    int  `json:"lastPage"`
+			PerPage     int  `json:"perPage"`
+			HasNextPage bool `json:"hasNextPage"`
+		} `json:"pageInfo"`
+		Edges []struct {
+			Role        string `json:"role"`
+			VoiceActors []voiceActor
+			Node        struct {
+				DateOfBirth struct {
+					Day   int `json:"day"`
+					Month int `json:"month"`
+					Year  int `json:"year"`
+				} `json:"dateOfBirth"`
+				Age  string `json:"age"`
+				ID   int    `json:"id"`
+				Name struct {
+					Full   string `json:"full"`
+					Native string `json:"native"`
+				} `json:"name"`
+				Image struct {
+					Large  string `json:"large"`
+					Medium string `json:"medium"`
+				} `json:"image"`
+				Description string `json:"description"`
+				SiteURL     string `json:"siteUrl"`
+			} `json:"node"`
+		} `json:"edges"`
+	} `json:"characters"`
+}
+
+// StaffEdge é um papel de uma pessoa na produção; a mesma pessoa aparece
+// em uma edge por papel (ex.: "Director" e "Storyboard (eps 1-2)").
+type StaffEdge struct {
+	Role string `json:"role"`
+	Node struct {
+		ID    int
+		Age   int
+		Image struct {
+			Large string
+		}
+		Gender   string
+		HomeTown string
+		Name     struct {
+			Full   string
+			Native string
+		}
+		SiteUrl string
+	}
+}
+
+// StudioEdge liga a mídia a um estúdio ou empresa. IsMain marca os
+// estúdios principais; produtoras e distribuidoras vêm com IsAnimationStudio false.
+type StudioEdge struct {
+	IsMain bool `json:"isMain"`
+	Node   struct {
+		ID                int    `json:"id"`
+		Name              string `json:"name"`
+		SiteUrl           string `json:"siteUrl"`
+		IsAnimationStudio bool   `json:"isAnimationStudio"`
+	} `json:"node"`
+}
+
+// RelationEdge é uma relação da mídia (SEQUEL, PREQUEL, ADAPTATION, ...).
+type RelationEdge struct {
+	RelationType string `json:"relationType"`
+	Node         struct {
+		ID        int    `json:"id"`
+		Type      string `json:"type"`
+		Format    string `json:"format"`
+		Title     Title  `json:"title"`
+		StartDate struct {
+			Day   int `json:"day"`
+			Month int `json:"month"`
+			Year  int `json:"year"`
+		} `json:"startDate"`
+	} `json:"node"`
 }
 
 type StreamingEpisode struct {
@@ -104,8 +304,10 @@ type StreamingEpisode struct {
 }
 
 type voiceActor struct {
+	ID   int `json:"id"`
 	Name struct {
-		Full string
+		Full   string
+		Native string
 	}
 	Image struct {
 		Large string
@@ -160,197 +362,111 @@ type Title struct {
 	UserPreferred string
 }
 
-func fetchAnimeCharacters(search string, page, perPage int) (*ResponseAnilist, error) {
-	query := `
-    query ($search: String!, $page: Int = 1, $perPage: Int = 50) {
-      Media(search: $search, type: ANIME, isAdult: false) {
-        id
-        title {
-			romaji
-			english
-			userPreferred
-			native
-		}
-		type
-		staff {
-			edges {
-				node {
-					id
-					age
-					image {
-						large
-					}
-					gender
-					homeTown
-					name {
-						full
-						native
-					}
-					siteUrl
-				}
-			}
-		}
-		studios {
-			nodes {
-				name
-				siteUrl
-			}
-		}
-        description
-        averageScore
-        countryOfOrigin
-		source
-		duration
-        episodes
-        format
-		streamingEpisodes {
-			site
-			thumbnail
-			title
-			url 
-		}
-        startDate {
-          day
-          month
-          year
-        }
-        endDate {
-          day
-          month
-          year
-        }
-        isAdult
-        synonyms
-		status
-        characters(page: $page, perPage: $perPage) {
-          pageInfo {
-            currentPage
-            lastPage
-            perPage
-            hasNextPage
-          }
-          edges {
-            voiceActors {
-				name {
-					full
-				}
-				image {
-					large
-				}
-				languageV2
-				siteUrl
-				homeTown
-				gender
-				age
-				dateOfBirth {
-					day
-					month
-					year 
-				}
-				dateOfDeath {
-					day
-					month
-					year
-				}
-        	}
-            node {
-			  id
-              dateOfBirth {
-                day
-                month
-                year
-              }
-              age
-              name {
-                full
-                native
-              }
-              image {
-                large
-                medium
-              }
-              description
-              siteUrl
-            }
-          }
-        }
-      }
-    }
-    `
-	variables := map[string]interface{}{
-		"search":  search,
-		"page":    page,
-		"perPage": perPage,
+func (t Title) Titles() dto.Titles {
+	return dto.Titles{
+		Romaji:        t.Romaji,
+		English:       t.English,
+		Native:        t.Native,
+		UserPreferred: t.UserPreferred,
 	}
+}
 
-	body := map[string]interface{}{
-		"query":     query,
-		"variables": variables,
-	}
+// MediaType é o tipo de mídia da AniList consultado pelo client.
+type MediaType string
 
-	headers := map[string]string{
-		"Content-Type": "application/json",
-	}
+const (
+	MediaAnime MediaType = "ANIME"
+	MediaManga MediaType = "MANGA"
+)
 
-	req, err := HTTPPostWithHeaders(anilistURL, body, headers)
-	if err != nil {
-		return nil, err
+// mediaLookup identifica uma mídia na AniList: pelo ID quando já é
+// conhecido, senão pela busca por título. mediaType vazio vale MediaAnime.
+// Com skipCharacters a consulta não traz personagens, só a mídia e a staff.
+type mediaLookup struct {
+	id             int
+	search         string
+	mediaType      MediaType
+	skipCharacters bool
+}
+
+func (l mediaLookup) variables() map[string]interface{} {
+	mediaType := l.mediaType
+	if mediaType == "" {
+		mediaType = MediaAnime
+	}
+	variables := map[string]interface{}{"type": mediaType}
+	if l.id != 0 {
+		variables["id"] = l.id
+	} else {
+		variables["search"] = l.search
 	}
-	var response ResponseAnilist
-	err = json.Unmarshal(req, &response)
+	if l.skipCharacters {
+		variables["withCharacters"] = false
+	}
+	return variables
+}
+
+func (c *AniListClient) fetchAnimeCharacters(ctx context.Context, lookup mediaLookup, page, perPage int) (*ResponseAnilist, error) {
+	variables := lookup.variables()
+	variables["page"] = page
+	variables["perPage"] = perPage
+
+	data, err := execute[mediaData[MediaDetail]](ctx, c, mediaQuery, variables)
 	if err != nil {
 		return nil, err
 	}
-
-	return &response, nil
+	return &ResponseAnilist{Data: *data}, nil
 }
 
-type respType struct {
-	Data struct{ Media struct{ Type string } }
+type ResponseJustType struct {
+	Data mediaData[struct{ Type string }]
 }
 
-func FetchJustType(search string) (*respType, error) {
-	query := `
-    query ($search: String!) {
-      Media(search: $search, type: ANIME, isAdult: false) {
-		type
-      }
-    }
-    `
-	variables := map[string]interface{}{
-		"search": search,
-	}
-
-	body := map[string]interface{}{
-		"query":     query,
-		"variables": variables,
-	}
+func (c *AniListClient) FetchJustType(ctx context.Context, search string) (*ResponseJustType, error) {
+	return c.fetchJustType(ctx, mediaLookup{search: search})
+}
 
-	headers := map[string]string{
-		"Content-Type": "application/json",
-	}
+// FetchJustTypeByID é como FetchJustType, mas usa o ID AniList já salvo no anime.
+func (c *AniListClient) FetchJustTypeByID(ctx context.Context, id int) (*ResponseJustType, error) {
+	return c.fetchJustType(ctx, mediaLookup{id: id})
+}
 
-	req, err := HTTPPostWithHeaders(anilistURL, body, headers)
-	if err != nil {
-		return nil, err
-	}
-	var response respType
-	err = json.Unmarshal(req, &response)
+func (c *AniListClient) fetchJustType(ctx context.Context, lookup mediaLookup) (*ResponseJustType, error) {
+	data, err := execute[mediaData[struct{ Type string }]](ctx, c, mediaTypeQuery, lookup.variables())
 	if err != nil {
 		return nil, err
 	}
+	return &ResponseJustType{Data: *data}, nil
+}
+
+func (c *AniListClient) FetchAllAnimeCharacters(ctx context.Context, search string, perPage int) ([]CharacterEdge, *ResponseAnilist, error) {
+	return c.fetchAllAnimeCharacters(ctx, mediaLookup{search: search}, perPage)
+}
+
+// FetchAllAnimeCharactersByID é como FetchAllAnimeCharacters, mas usa o ID
+// AniList já salvo no anime em vez de pesquisar pelo título.
+func (c *AniListClient) FetchAllAnimeCharactersByID(ctx context.Context, id int, perPage int) ([]CharacterEdge, *ResponseAnilist, error) {
+	return c.fetchAllAnimeCharacters(ctx, mediaLookup{id: id}, perPage)
+}
 
-	return &response, nil
+// FetchMangaByID busca o mangá pelo ID AniList com toda a staff, sem
+// personagens: só as páginas de staff são percorridas. Os campos só de anime
+// (episódios, estúdios, agenda) vêm vazios.
+func (c *AniListClient) FetchMangaByID(ctx context.Context, id int, perPage int) (*ResponseAnilist, error) {
+	_, resp, err := c.fetchAllAnimeCharacters(ctx, mediaLookup{id: id, mediaType: MediaManga, skipCharacters: true}, perPage)
+	return resp, err
 }
 
-func FetchAllAnimeCharacters(search string, perPage int) ([]CharacterEdge, *ResponseAnilist, error) {
+func (c *AniListClient) fetchAllAnimeCharacters(ctx context.Context, lookup mediaLookup, perPage int) ([]CharacterEdge, *ResponseAnilist, error) {
 	page := 1
 	var allEdges []CharacterEdge
 	var fullResponse *ResponseAnilist
+	var staff []StaffEdge
 	seen := make(map[int]bool)
+	seenStaff := make(map[string]bool)
 
 	for {
-		resp, err := fetchAnimeCharacters(search, page, perPage)
+		resp, err := c.fetchAnimeCharacters(ctx, lookup, page, perPage)
 		if err != nil {
 			return nil, nil, err
 		}
@@ -412,14 +528,30 @@ func FetchAllAnimeCharacters(search string, perPage int) ([]CharacterEdge, *Resp
 			})
 		}
 
+		for _, edge := range resp.Data.Media.Staff.Edges {
+			key := fmt.Sprintf("%d/%s", edge.Node.ID, edge.Role)
+			if seenStaff[key] {
+				continue
+			}
+			seenStaff[key] = true
+			staff = append(staff, edge)
+		}
+
 		pageInfo := resp.Data.Media.Characters.PageInfo
-		fmt.Printf("Fetched page %d of %d\n", pageInfo.CurrentPage, pageInfo.LastPage)
+		if !lookup.skipCharacters {
+			fmt.Printf("Fetched page %d of %d\n", pageInfo.CurrentPage, pageInfo.LastPage)
+		}
 
-		if !pageInfo.HasNextPage || page >= pageInfo.LastPage {
+		// personagens e staff usam o mesmo $page; continua enquanto algum tiver mais páginas
+		charactersDone := !pageInfo.HasNextPage || page >= pageInfo.LastPage
+		if charactersDone && !resp.Data.Media.Staff.PageInfo.HasNextPage {
 			break
 		}
 		page++
 	}
 
+	if fullResponse != nil {
+		fullResponse.Data.Media.Staff.Edges = staff
+	}
 	return allEdges, fullResponse, nil
 }
@@ -0,0 +1,36 @@
+package logic
+
+import "fmt"
+
+// AdultPolicy define como o client trata mídias adultas (isAdult) da AniList.
+type AdultPolicy string
+
+const (
+	// AdultExclude filtra as mídias adultas nas consultas (padrão).
+	AdultExclude AdultPolicy = "exclude"
+	// AdultInclude traz as mídias adultas como qualquer outra.
+	AdultInclude AdultPolicy = "include"
+	// AdultFlag traz as mídias adultas, mas os scripts as gravam ocultas
+	// (hidden) para o app só exibir depois de uma decisão explícita.
+	AdultFlag AdultPolicy = "flag"
+)
+
+// ParseAdultPolicy converte o valor de ANILIST_ADULT; vazio vira AdultExclude.
+func ParseAdultPolicy(s string) (AdultPolicy, error) {
+	switch p := AdultPolicy(s); p {
+	case "":
+		return AdultExclude, nil
+	case AdultExclude, AdultInclude, AdultFlag:
+		return p, nil
+	}
+	return "", fmt.Errorf("política de conteúdo adulto inválida %q (use exclude, include ou flag)", s)
+}
+
+// withAdult acrescenta a variável $isAdult das consultas conforme a política:
+// false exclui as mídias adultas; ausente (null) não filtra.
+func (c *AniListClient) withAdult(variables map[string]interface{}) map[string]interface{} {
+	if c.Adult == "" || c.Adult == AdultExclude {
+		variables["isAdult"] = false
+	}
+	return variables
+}
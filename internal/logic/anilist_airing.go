@@ -0,0 +1,41 @@
+package logic
+
+import (
+	"context"
+	"time"
+)
+
+// AiringEpisode é um episódio agendado; AiringAt vem da AniList em segundos Unix.
+type AiringEpisode struct {
+	Episode  int   `json:"episode"`
+	AiringAt int64 `json:"airingAt"`
+}
+
+func (e AiringEpisode) Time() time.Time {
+	return time.Unix(e.AiringAt, 0).UTC()
+}
+
+// AiringInfo são os campos que mudam enquanto o anime está em exibição.
+type AiringInfo struct {
+	ID                int            `json:"id"`
+	Status            string         `json:"status"`
+	Episodes          int            `json:"episodes"`
+	NextAiringEpisode *AiringEpisode `json:"nextAiringEpisode"`
+	AiringSchedule    struct {
+		Nodes []AiringEpisode `json:"nodes"`
+	} `json:"airingSchedule"`
+	EndDate struct {
+		Day   int `json:"day"`
+		Month int `json:"month"`
+		Year  int `json:"year"`
+	} `json:"endDate"`
+}
+
+// FetchAiringByID busca status, episódios e a agenda dos próximos episódios.
+func (c *AniListClient) FetchAiringByID(ctx context.Context, id int) (*AiringInfo, error) {
+	data, err := execute[mediaData[AiringInfo]](ctx, c, airingQuery, mediaLookup{id: id}.variables())
+	if err != nil {
+		return nil, err
+	}
+	return &data.Media, nil
+}
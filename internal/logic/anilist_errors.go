@@ -0,0 +1,97 @@
+package logic
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+var (
+	// ErrMediaNotFound indica que a AniList não encontrou a mídia pesquisada.
+	ErrMediaNotFound = errors.New("AniList: media não encontrada")
+	// ErrRateLimited indica que a requisição recebeu 429 em todas as tentativas.
+	ErrRateLimited = errors.New("AniList: limite de requisições excedido")
+	// ErrGraphQL casa com qualquer resposta de erro da AniList (ver GraphQLError).
+	ErrGraphQL = errors.New("AniList: erro GraphQL")
+)
+
+type GraphQLLocation struct {
+	Line   int `json:"line"`
+	Column int `json:"column"`
+}
+
+type GraphQLErrorItem struct {
+	Message   string            `json:"message"`
+	Status    int               `json:"status"`
+	Locations []GraphQLLocation `json:"locations"`
+}
+
+// GraphQLError é uma resposta da AniList com status HTTP de erro ou com o
+// array errors preenchido. Use errors.Is com ErrMediaNotFound, ErrRateLimited
+// ou ErrGraphQL para decidir o que fazer.
+type GraphQLError struct {
+	Status int
+	Errors []GraphQLErrorItem
+}
+
+func (e *GraphQLError) Error() string {
+	if len(e.Errors) == 0 {
+		return fmt.Sprintf("AniList: status %d", e.Status)
+	}
+
+	msgs := make([]string, 0, len(e.Errors))
+	for _, item := range e.Errors {
+		msg := item.Message
+		for _, loc := range item.Locations {
+			msg += fmt.Sprintf(" (linha %d, coluna %d)", loc.Line, loc.Column)
+		}
+		msgs = append(msgs, msg)
+	}
+	return fmt.Sprintf("AniList: status %d: %s", e.Status, strings.Join(msgs, "; "))
+}
+
+func (e *GraphQLError) Is(target error) bool {
+	switch target {
+	case ErrGraphQL:
+		return true
+	case ErrMediaNotFound:
+		return e.hasStatus(http.StatusNotFound)
+	case ErrRateLimited:
+		return e.hasStatus(http.StatusTooManyRequests)
+	}
+	return false
+}
+
+// Temporary indica se vale a pena repetir a requisição.
+func (e *GraphQLError) Temporary() bool {
+	return e.hasStatus(http.StatusTooManyRequests) || e.Status >= http.StatusInternalServerError
+}
+
+func (e *GraphQLError) hasStatus(status int) bool {
+	if e.Status == status {
+		return true
+	}
+	for _, item := range e.Errors {
+		if item.Status == status {
+			return true
+		}
+	}
+	return false
+}
+
+// checkGraphQLResponse devolve um *GraphQLError quando o status HTTP não é
+// 2xx ou quando o corpo traz o array errors.
+func checkGraphQLResponse(status int, body []byte) error {
+	var envelope struct {
+		Errors []GraphQLErrorItem `json:"errors"`
+	}
+	// o corpo pode nem ser JSON (ex.: página de erro do proxy)
+	_ = json.Unmarshal(body, &envelope)
+
+	if len(envelope.Errors) == 0 && status >= 200 && status < 300 {
+		return nil
+	}
+	return &GraphQLError{Status: status, Errors: envelope.Errors}
+}
@@ -0,0 +1,146 @@
+package logic
+
+import (
+	"context"
+	"embed"
+	"encoding/json"
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// As consultas ficam em graphql/*.graphql e os fragments compartilhados em
+// graphql/fragments/*.graphql, um fragment por arquivo.
+//
+//go:embed graphql
+var graphqlFiles embed.FS
+
+var (
+	fragmentDefinition = regexp.MustCompile(`^fragment\s+(\w+)\s+on\s`)
+	fragmentSpread     = regexp.MustCompile(`\.\.\.\s*(\w+)`)
+)
+
+// graphQLQuery é uma consulta carregada de graphql/, já com os fragments que usa.
+type graphQLQuery struct {
+	name string
+	text string
+}
+
+var (
+	mediaQuery       = mustLoadQuery("media")
+	mediaTypeQuery   = mustLoadQuery("media_type")
+	airingQuery      = mustLoadQuery("airing")
+	searchMediaQuery = mustLoadQuery("search_media")
+	seasonPageQuery  = mustLoadQuery("season_page")
+	statsQuery       = mustLoadQuery("stats")
+)
+
+// mustLoadQuery lê graphql/<name>.graphql e acrescenta a definição de cada
+// fragment usado, direta ou indiretamente. A AniList rejeita fragments
+// definidos e não usados, então só entram os necessários.
+func mustLoadQuery(name string) graphQLQuery {
+	text, err := graphqlFiles.ReadFile(path.Join("graphql", name+".graphql"))
+	if err != nil {
+		panic(fmt.Sprintf("consulta GraphQL %s não encontrada: %v", name, err))
+	}
+
+	fragments, err := loadFragments()
+	if err != nil {
+		panic(err)
+	}
+
+	var (
+		b        strings.Builder
+		included = make(map[string]bool)
+		include  func(src string)
+	)
+	b.Write(text)
+	include = func(src string) {
+		for _, m := range fragmentSpread.FindAllStringSubmatch(src, -1) {
+			spread := m[1]
+			if spread == "on" || included[spread] {
+				continue
+			}
+			def, ok := fragments[spread]
+			if !ok {
+				panic(fmt.Sprintf("consulta GraphQL %s usa o fragment %s, que não existe", name, spread))
+			}
+			included[spread] = true
+			b.WriteString("\n")
+			b.WriteString(def)
+			include(def)
+		}
+	}
+	include(string(text))
+
+	return graphQLQuery{name: name, text: b.String()}
+}
+
+// loadFragments devolve a definição de cada fragment pelo nome.
+func loadFragments() (map[string]string, error) {
+	entries, err := graphqlFiles.ReadDir("graphql/fragments")
+	if err != nil {
+		return nil, fmt.Errorf("erro ao ler os fragments GraphQL: %w", err)
+	}
+
+	fragments := make(map[string]string, len(entries))
+	for _, entry := range entries {
+		file := path.Join("graphql/fragments", entry.Name())
+		def, err := graphqlFiles.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("erro ao ler %s: %w", file, err)
+		}
+		m := fragmentDefinition.FindSubmatch(def)
+		if m == nil {
+			return nil, fmt.Errorf("%s não começa com uma definição de fragment", file)
+		}
+		fragments[string(m[1])] = string(def)
+	}
+	return fragments, nil
+}
+
+// mediaData é o data das consultas que devolvem um único Media.
+type mediaData[T any] struct {
+	Media T `json:"Media"`
+}
+
+// pageData é o data das consultas paginadas de Page.media.
+type pageData[T any] struct {
+	Page struct {
+		PageInfo struct {
+			HasNextPage bool `json:"hasNextPage"`
+		} `json:"pageInfo"`
+		Media []T `json:"media"`
+	} `json:"Page"`
+}
+
+// execute envia a consulta com variables (mais o isAdult da política do
+// client) e decodifica o campo data da resposta em T. Erros HTTP e GraphQL
+// voltam como *GraphQLError, do mesmo jeito que em post.
+func execute[T any](ctx context.Context, c *AniListClient, query graphQLQuery, variables map[string]interface{}) (*T, error) {
+	body := map[string]interface{}{
+		"query":     query.text,
+		"variables": c.withAdult(variables),
+	}
+
+	headers := map[string]string{
+		"Content-Type": "application/json",
+	}
+
+	data, err := c.post(ctx, body, headers)
+	if err != nil {
+		return nil, err
+	}
+
+	var response struct {
+		Data *T `json:"data"`
+	}
+	if err := json.Unmarshal(data, &response); err != nil {
+		return nil, fmt.Errorf("AniList: resposta inválida para %s: %w", query.name, err)
+	}
+	if response.Data == nil {
+		return nil, fmt.Errorf("AniList: resposta sem data para %s", query.name)
+	}
+	return response.Data, nil
+}
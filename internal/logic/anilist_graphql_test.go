@@ -0,0 +1,260 @@
+package logic
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gpt-utils/internal/dto"
+)
+
+// graphQLRequest é o corpo enviado pelo client.
+type graphQLRequest struct {
+	Query     string                 `json:"query"`
+	Variables map[string]interface{} `json:"variables"`
+}
+
+// fakeAniList é um servidor GraphQL que responde com handle e guarda as
+// requisições recebidas.
+type fakeAniList struct {
+	mu       sync.Mutex
+	requests []graphQLRequest
+	handle   func(w http.ResponseWriter, req graphQLRequest, n int)
+}
+
+func (f *fakeAniList) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var req graphQLRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	f.mu.Lock()
+	f.requests = append(f.requests, req)
+	n := len(f.requests)
+	f.mu.Unlock()
+	f.handle(w, req, n)
+}
+
+func (f *fakeAniList) calls() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return len(f.requests)
+}
+
+// newTestAniListClient aponta um client para o servidor fake, sem as
+// esperas do rate limit e do backoff.
+func newTestAniListClient(t *testing.T, handle func(w http.ResponseWriter, req graphQLRequest, n int)) (*AniListClient, *fakeAniList) {
+	t.Helper()
+	fake := &fakeAniList{handle: handle}
+	server := httptest.NewServer(fake)
+	t.Cleanup(server.Close)
+
+	saved := backoff
+	backoff = func(int) time.Duration { return 0 }
+	t.Cleanup(func() { backoff = saved })
+
+	c := NewAniListClient(server.Client())
+	c.URL = server.URL
+	c.Limiter.limit = 1 << 20
+	return c, fake
+}
+
+func writeJSON(w http.ResponseWriter, status int, body string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	w.Write([]byte(body))
+}
+
+func TestExecuteDecodesData(t *testing.T) {
+	c, fake := newTestAniListClient(t, func(w http.ResponseWriter, req graphQLRequest, n int) {
+		writeJSON(w, http.StatusOK, `{"data":{"Page":{"pageInfo":{"hasNextPage":true},"media":[
+			{"id":16498,"title":{"romaji":"Shingeki no Kyojin","english":"Attack on Titan"},"format":"TV","episodes":25,"startDate":{"year":2013,"month":4,"day":7}}
+		]}}}`)
+	})
+
+	media, hasNext, err := c.FetchSeasonPage(context.Background(), dto.AnimeSeason{Year: 2013, Season: "SPRING"}, 2, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !hasNext || len(media) != 1 {
+		t.Fatalf("hasNext = %v, media = %+v", hasNext, media)
+	}
+	m := media[0]
+	if m.ID != 16498 || m.Title.English != "Attack on Titan" || m.Episodes != 25 || m.StartDate.Month != 4 {
+		t.Errorf("media = %+v", m)
+	}
+
+	req := fake.requests[0]
+	if req.Variables["season"] != "SPRING" || req.Variables["seasonYear"] != float64(2013) || req.Variables["page"] != float64(2) {
+		t.Errorf("variables = %v", req.Variables)
+	}
+	if req.Variables["isAdult"] != false {
+		t.Errorf("isAdult = %v, quer false com a política padrão", req.Variables["isAdult"])
+	}
+	// só os fragments usados pela consulta vão junto
+	if !strings.Contains(req.Query, "fragment TitleFields on MediaTitle") {
+		t.Error("consulta sem o fragment TitleFields")
+	}
+	if strings.Contains(req.Query, "fragment AiringFields") {
+		t.Error("consulta com o fragment AiringFields, que não usa")
+	}
+}
+
+func TestExecuteWithoutData(t *testing.T) {
+	c, _ := newTestAniListClient(t, func(w http.ResponseWriter, req graphQLRequest, n int) {
+		writeJSON(w, http.StatusOK, `{}`)
+	})
+
+	if _, err := c.FetchJustTypeByID(context.Background(), 1); err == nil || !strings.Contains(err.Error(), "sem data") {
+		t.Fatalf("err = %v, quer resposta sem data", err)
+	}
+}
+
+func TestPostRetriesTemporaryErrors(t *testing.T) {
+	c, fake := newTestAniListClient(t, func(w http.ResponseWriter, req graphQLRequest, n int) {
+		switch n {
+		case 1:
+			writeJSON(w, http.StatusBadGateway, `<html>bad gateway</html>`)
+		case 2:
+			w.Header().Set("Retry-After", "0")
+			writeJSON(w, http.StatusTooManyRequests, `{"errors":[{"message":"Too Many Requests.","status":429}]}`)
+		default:
+			writeJSON(w, http.StatusOK, `{"data":{"Media":{"type":"ANIME"}}}`)
+		}
+	})
+
+	resp, err := c.FetchJustTypeByID(context.Background(), 16498)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Data.Media.Type != "ANIME" {
+		t.Errorf("type = %q", resp.Data.Media.Type)
+	}
+	if fake.calls() != 3 {
+		t.Errorf("%d requisições, quer 3", fake.calls())
+	}
+}
+
+func TestPostBacksOffOnBodyReadError(t *testing.T) {
+	c, fake := newTestAniListClient(t, func(w http.ResponseWriter, req graphQLRequest, n int) {
+		if n == 1 {
+			// corpo menor que o Content-Length: a leitura falha no client
+			w.Header().Set("Content-Length", "100")
+			w.WriteHeader(http.StatusOK)
+			w.Write([]byte(`{"data":`))
+			return
+		}
+		writeJSON(w, http.StatusOK, `{"data":{"Media":{"type":"ANIME"}}}`)
+	})
+	var waits []int
+	backoff = func(attempt int) time.Duration {
+		waits = append(waits, attempt)
+		return 0
+	}
+
+	if _, err := c.FetchJustTypeByID(context.Background(), 16498); err != nil {
+		t.Fatal(err)
+	}
+	if fake.calls() != 2 || len(waits) != 1 || waits[0] != 1 {
+		t.Errorf("%d requisições e esperas %v; quer 2 e [1]", fake.calls(), waits)
+	}
+}
+
+func TestPostClassifiesErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		status int
+		body   string
+		want   error
+		calls  int
+	}{
+		{"404 HTTP", http.StatusNotFound, `{"errors":[{"message":"Not Found.","status":404}],"data":{"Media":null}}`, ErrMediaNotFound, 1},
+		{"404 só no corpo", http.StatusOK, `{"errors":[{"message":"Not Found.","status":404}],"data":{"Media":null}}`, ErrMediaNotFound, 1},
+		{"429 em todas as tentativas", http.StatusTooManyRequests, `{"errors":[{"message":"Too Many Requests.","status":429}]}`, ErrRateLimited, aniListMaxAttempts},
+		{"consulta inválida", http.StatusBadRequest, `{"errors":[{"message":"Unknown argument","status":400,"locations":[{"line":2,"column":9}]}]}`, ErrGraphQL, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, fake := newTestAniListClient(t, func(w http.ResponseWriter, req graphQLRequest, n int) {
+				w.Header().Set("Retry-After", "0")
+				writeJSON(w, tt.status, tt.body)
+			})
+
+			_, err := c.FetchJustTypeByID(context.Background(), 16498)
+			if !errors.Is(err, tt.want) {
+				t.Fatalf("err = %v, quer %v", err, tt.want)
+			}
+			var gqlErr *GraphQLError
+			if !errors.As(err, &gqlErr) {
+				t.Fatalf("err = %T, quer *GraphQLError", err)
+			}
+			if tt.want != ErrMediaNotFound && errors.Is(err, ErrMediaNotFound) {
+				t.Errorf("%v classificado como ErrMediaNotFound", err)
+			}
+			if fake.calls() != tt.calls {
+				t.Errorf("%d requisições, quer %d", fake.calls(), tt.calls)
+			}
+		})
+	}
+}
+
+func TestPostObservesRateLimitHeaders(t *testing.T) {
+	reset := time.Now().Add(time.Hour).Truncate(time.Second)
+	c, _ := newTestAniListClient(t, func(w http.ResponseWriter, req graphQLRequest, n int) {
+		w.Header().Set("X-RateLimit-Limit", "30")
+		w.Header().Set("X-RateLimit-Remaining", "0")
+		w.Header().Set("X-RateLimit-Reset", strconv.FormatInt(reset.Unix(), 10))
+		writeJSON(w, http.StatusOK, `{"data":{"Media":{"type":"MANGA"}}}`)
+	})
+
+	if _, err := c.FetchJustTypeByID(context.Background(), 30013); err != nil {
+		t.Fatal(err)
+	}
+	if c.Limiter.limit != 30 || c.Limiter.remaining != 0 {
+		t.Errorf("limit = %d, remaining = %d", c.Limiter.limit, c.Limiter.remaining)
+	}
+	if !c.Limiter.reset.Equal(reset) {
+		t.Errorf("fim da janela em %v, quer %v", c.Limiter.reset, reset)
+	}
+
+	// a próxima requisição espera o reset; o contexto cancela antes
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := c.FetchJustTypeByID(ctx, 30013); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("err = %v, quer context.DeadlineExceeded", err)
+	}
+}
+
+func TestFetchMangaByIDPagesOnlyStaff(t *testing.T) {
+	c, fake := newTestAniListClient(t, func(w http.ResponseWriter, req graphQLRequest, n int) {
+		staff := `{"pageInfo":{"hasNextPage":true},"edges":[{"role":"Story & Art","node":{"id":96879,"name":{"full":"Hajime Isayama"}}}]}`
+		if n == 2 {
+			staff = `{"pageInfo":{"hasNextPage":false},"edges":[{"role":"Editing","node":{"id":1,"name":{"full":"Editor"}}}]}`
+		}
+		writeJSON(w, http.StatusOK, `{"data":{"Media":{"id":53390,"type":"MANGA","staff":`+staff+`}}}`)
+	})
+
+	resp, err := c.FetchMangaByID(context.Background(), 53390, 25)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fake.calls() != 2 {
+		t.Errorf("%d requisições, quer 2", fake.calls())
+	}
+	for _, req := range fake.requests {
+		if req.Variables["withCharacters"] != false || req.Variables["type"] != "MANGA" {
+			t.Errorf("variables = %v", req.Variables)
+		}
+	}
+	if staff := resp.Data.Media.Staff.Edges; len(staff) != 2 || staff[0].Node.Name.Full != "Hajime Isayama" {
+		t.Errorf("staff = %+v", staff)
+	}
+}
@@ -0,0 +1,124 @@
+package logic
+
+import (
+	"context"
+	"sort"
+
+	"github.com/gpt-utils/internal/dto"
+	"github.com/gpt-utils/internal/logic/utils"
+)
+
+// MediaCandidate é um resultado da busca paginada usado para escolher o
+// anime certo antes de buscar os dados completos pelo ID.
+type MediaCandidate struct {
+	ID        int      `json:"id"`
+	IDMal     int      `json:"idMal"`
+	Title     Title    `json:"title"`
+	Synonyms  []string `json:"synonyms"`
+	Format    string   `json:"format"`
+	Episodes  int      `json:"episodes"`
+	StartDate struct {
+		Year int `json:"year"`
+	} `json:"startDate"`
+}
+
+// Titles devolve todas as variantes de título não vazias do candidato.
+func (m MediaCandidate) Titles() []string {
+	var titles []string
+	for _, t := range append([]string{m.Title.Romaji, m.Title.English, m.Title.Native, m.Title.UserPreferred}, m.Synonyms...) {
+		if t != "" {
+			titles = append(titles, t)
+		}
+	}
+	return titles
+}
+
+type ScoredCandidate struct {
+	Media MediaCandidate
+	Score float64
+}
+
+// SearchAnimeCandidates busca até perPage animes para o título informado.
+func (c *AniListClient) SearchAnimeCandidates(ctx context.Context, search string, perPage int) ([]MediaCandidate, error) {
+	return c.SearchMediaCandidates(ctx, MediaAnime, search, perPage)
+}
+
+// SearchMediaCandidates busca até perPage mídias do tipo informado para o título.
+func (c *AniListClient) SearchMediaCandidates(ctx context.Context, mediaType MediaType, search string, perPage int) ([]MediaCandidate, error) {
+	data, err := execute[pageData[MediaCandidate]](ctx, c, searchMediaQuery, map[string]interface{}{
+		"search":  search,
+		"type":    mediaType,
+		"perPage": perPage,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return data.Page.Media, nil
+}
+
+// ScoreCandidate dá uma nota de 0 a 1 para o quanto o candidato corresponde
+// ao anime. O título pesa 80% e usa a melhor comparação entre as variantes
+// de TitleVariants e os títulos do candidato. Ano de estreia, formato e
+// número de episódios somam ou subtraem pontos quando os dois lados
+// conhecem o valor.
+func ScoreCandidate(anime dto.Anime, media MediaCandidate) float64 {
+	ours := anime.TitleVariants()
+
+	var titleScore float64
+	for _, a := range ours {
+		for _, b := range media.Titles() {
+			if s := utils.Similarity(a, b); s > titleScore {
+				titleScore = s
+			}
+		}
+	}
+
+	score := titleScore * 0.8
+
+	if anime.StartDate.Year != 0 && media.StartDate.Year != 0 {
+		switch diff := anime.StartDate.Year - media.StartDate.Year; {
+		case diff == 0:
+			score += 0.1
+		case diff == 1 || diff == -1:
+			score += 0.05
+		default:
+			score -= 0.15
+		}
+	}
+
+	if anime.Format != "" && media.Format != "" {
+		if anime.Format == media.Format {
+			score += 0.05
+		} else {
+			score -= 0.05
+		}
+	}
+
+	if anime.Episodes != 0 && media.Episodes != 0 {
+		if anime.Episodes == media.Episodes {
+			score += 0.05
+		} else {
+			score -= 0.05
+		}
+	}
+
+	switch {
+	case score < 0:
+		return 0
+	case score > 1:
+		return 1
+	}
+	return score
+}
+
+// RankCandidates devolve os candidatos ordenados da maior para a menor nota.
+func RankCandidates(anime dto.Anime, candidates []MediaCandidate) []ScoredCandidate {
+	ranked := make([]ScoredCandidate, 0, len(candidates))
+	for _, m := range candidates {
+		ranked = append(ranked, ScoredCandidate{Media: m, Score: ScoreCandidate(anime, m)})
+	}
+	sort.SliceStable(ranked, func(i, j int) bool {
+		return ranked[i].Score > ranked[j].Score
+	})
+	return ranked
+}
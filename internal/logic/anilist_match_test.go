@@ -0,0 +1,76 @@
+package logic
+
+import (
+	"math"
+	"testing"
+
+	"github.com/gpt-utils/internal/dto"
+)
+
+// matchThreshold é o --match-threshold padrão dos scripts.
+const matchThreshold = 0.75
+
+func candidate(id int, romaji, format string, episodes, year int) MediaCandidate {
+	c := MediaCandidate{ID: id, Format: format, Episodes: episodes}
+	c.Title.Romaji = romaji
+	c.StartDate.Year = year
+	return c
+}
+
+func TestScoreCandidate(t *testing.T) {
+	naruto := func(format string, episodes, year int) dto.Anime {
+		return dto.Anime{Title: "Naruto", Format: format, Episodes: episodes, StartDate: dto.StartDate{Year: year}}
+	}
+
+	tests := []struct {
+		name   string
+		anime  dto.Anime
+		media  MediaCandidate
+		want   float64
+		accept bool
+	}{
+		{"tudo igual", naruto("TV", 220, 2002), candidate(20, "NARUTO", "TV", 220, 2002), 1, true},
+		{"só o título", naruto("", 0, 0), candidate(20, "Naruto", "TV", 220, 2002), 0.8, true},
+		{"ano vizinho", naruto("", 0, 2003), candidate(20, "Naruto", "", 0, 2002), 0.85, true},
+		{"ano distante", naruto("", 0, 2004), candidate(20, "Naruto", "", 0, 2002), 0.65, false},
+		{"formato diferente fica no limite", naruto("MOVIE", 0, 0), candidate(20, "Naruto", "TV", 0, 0), 0.75, true},
+		{"formato e episódios diferentes", naruto("MOVIE", 1, 0), candidate(20, "Naruto", "TV", 220, 0), 0.7, false},
+		{"episódios iguais compensam o formato", naruto("MOVIE", 220, 0), candidate(20, "Naruto", "TV", 220, 0), 0.8, true},
+		{"título diferente não passa de zero", naruto("", 0, 1990), candidate(21, "Bleach", "", 0, 2004), 0, false},
+		{
+			"casa por outra variante do título",
+			dto.Anime{Title: "Attack on Titan", Titles: dto.Titles{Romaji: "Shingeki no Kyojin"}},
+			candidate(16498, "Shingeki no Kyojin", "TV", 25, 2013),
+			0.8, true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ScoreCandidate(tt.anime, tt.media)
+			if math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("ScoreCandidate = %v, quer %v", got, tt.want)
+			}
+			if accept := got >= matchThreshold; accept != tt.accept {
+				t.Errorf("ScoreCandidate = %v: aceito = %v, quer %v", got, accept, tt.accept)
+			}
+		})
+	}
+}
+
+func TestRankCandidates(t *testing.T) {
+	anime := dto.Anime{Title: "Naruto", StartDate: dto.StartDate{Year: 2002}}
+	ranked := RankCandidates(anime, []MediaCandidate{
+		candidate(1, "Boruto", "TV", 0, 2017),
+		candidate(2, "Naruto", "TV", 0, 2002),
+		candidate(3, "Naruto: Shippuuden", "TV", 0, 2007),
+	})
+	if len(ranked) != 3 || ranked[0].Media.ID != 2 {
+		t.Fatalf("ordem = %+v", ranked)
+	}
+	for i := 1; i < len(ranked); i++ {
+		if ranked[i].Score > ranked[i-1].Score {
+			t.Errorf("candidato %d (%.2f) depois de um com nota menor (%.2f)", ranked[i].Media.ID, ranked[i].Score, ranked[i-1].Score)
+		}
+	}
+}
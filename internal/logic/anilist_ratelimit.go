@@ -0,0 +1,100 @@
+package logic
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// defaultAniListLimit é usado até a primeira resposta trazer X-RateLimit-Limit.
+const defaultAniListLimit = 90
+
+// RateLimiter espaça as requisições para a AniList conforme os headers
+// X-RateLimit-Limit, X-RateLimit-Remaining, X-RateLimit-Reset e Retry-After.
+// É seguro para uso concorrente: cada Wait reserva o próximo horário livre
+// e gasta uma requisição do saldo informado pela última resposta.
+type RateLimiter struct {
+	mu     sync.Mutex
+	window time.Duration
+	limit  int
+	// remaining é o saldo da janela atual; -1 quando a AniList não informou.
+	remaining int
+	// reset é quando a janela atual termina e o saldo volta ao limite.
+	reset time.Time
+	next  time.Time
+}
+
+func NewRateLimiter() *RateLimiter {
+	return &RateLimiter{
+		window:    time.Minute,
+		limit:     defaultAniListLimit,
+		remaining: -1,
+	}
+}
+
+// Wait bloqueia até que a próxima requisição possa ser enviada.
+func (l *RateLimiter) Wait(ctx context.Context) error {
+	l.mu.Lock()
+	now := time.Now()
+	at := l.next
+	if at.Before(now) {
+		at = now
+	}
+	// sem saldo, espera o fim da janela; os workers que já reservaram um
+	// horário gastam o saldo antes de a próxima resposta chegar
+	if l.remaining == 0 && l.reset.After(at) {
+		at = l.reset
+	}
+	if l.remaining > 0 {
+		l.remaining--
+	}
+	l.next = at.Add(l.window / time.Duration(l.limit))
+	l.mu.Unlock()
+
+	delay := time.Until(at)
+	if delay <= 0 {
+		return nil
+	}
+	return sleepContext(ctx, delay)
+}
+
+// Observe atualiza o limitador com os headers de uma resposta e devolve
+// quanto esperar antes de repetir a requisição quando ela levou 429.
+func (l *RateLimiter) Observe(resp *http.Response) time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	if n, err := strconv.Atoi(resp.Header.Get("X-RateLimit-Limit")); err == nil && n > 0 {
+		l.limit = n
+	}
+	// sem o header (ou inválido) o saldo passa a ser desconhecido, para que
+	// um valor antigo não segure nem libere as próximas requisições
+	if n, err := strconv.Atoi(resp.Header.Get("X-RateLimit-Remaining")); err == nil {
+		l.remaining = n
+		l.reset = now.Add(l.window)
+		if unix, err := strconv.ParseInt(resp.Header.Get("X-RateLimit-Reset"), 10, 64); err == nil {
+			l.reset = time.Unix(unix, 0)
+		}
+	} else {
+		l.remaining = -1
+	}
+
+	if resp.StatusCode == http.StatusTooManyRequests {
+		retryAfter := l.window
+		if secs, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil && secs >= 0 {
+			retryAfter = time.Duration(secs) * time.Second
+		}
+		l.pushNext(now.Add(retryAfter))
+		return retryAfter
+	}
+	return 0
+}
+
+func (l *RateLimiter) pushNext(t time.Time) {
+	if t.After(l.next) {
+		l.next = t
+	}
+}
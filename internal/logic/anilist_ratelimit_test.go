@@ -0,0 +1,140 @@
+package logic
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func rateLimitResponse(status int, headers map[string]string) *http.Response {
+	resp := &http.Response{StatusCode: status, Header: http.Header{}}
+	for k, v := range headers {
+		resp.Header.Set(k, v)
+	}
+	return resp
+}
+
+func TestRateLimiterObserve(t *testing.T) {
+	reset := time.Now().Add(30 * time.Second).Truncate(time.Second)
+
+	tests := []struct {
+		name          string
+		responses     []*http.Response
+		wantLimit     int
+		wantRemaining int
+		wantRetry     time.Duration
+		wantNextAfter time.Time
+		wantReset     time.Time
+	}{
+		{
+			name:          "sem headers mantém o padrão",
+			responses:     []*http.Response{rateLimitResponse(200, nil)},
+			wantLimit:     defaultAniListLimit,
+			wantRemaining: -1,
+		},
+		{
+			name: "limite e saldo vêm dos headers",
+			responses: []*http.Response{rateLimitResponse(200, map[string]string{
+				"X-RateLimit-Limit":     "30",
+				"X-RateLimit-Remaining": "12",
+			})},
+			wantLimit:     30,
+			wantRemaining: 12,
+		},
+		{
+			name: "saldo zerado segura até o reset",
+			responses: []*http.Response{rateLimitResponse(200, map[string]string{
+				"X-RateLimit-Remaining": "0",
+				"X-RateLimit-Reset":     strconv.FormatInt(reset.Unix(), 10),
+			})},
+			wantLimit:     defaultAniListLimit,
+			wantRemaining: 0,
+			wantReset:     reset,
+		},
+		{
+			name: "429 usa o Retry-After",
+			responses: []*http.Response{rateLimitResponse(http.StatusTooManyRequests, map[string]string{
+				"Retry-After":           "7",
+				"X-RateLimit-Remaining": "0",
+			})},
+			wantLimit:     defaultAniListLimit,
+			wantRemaining: 0,
+			wantRetry:     7 * time.Second,
+			wantNextAfter: time.Now().Add(6 * time.Second),
+		},
+		{
+			name: "resposta sem saldo descarta o saldo anterior",
+			responses: []*http.Response{
+				rateLimitResponse(200, map[string]string{"X-RateLimit-Remaining": "1"}),
+				rateLimitResponse(200, nil),
+			},
+			wantLimit:     defaultAniListLimit,
+			wantRemaining: -1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := NewRateLimiter()
+			var retry time.Duration
+			for _, resp := range tt.responses {
+				retry = l.Observe(resp)
+			}
+			if l.limit != tt.wantLimit || l.remaining != tt.wantRemaining {
+				t.Errorf("limit/remaining = %d/%d, quer %d/%d", l.limit, l.remaining, tt.wantLimit, tt.wantRemaining)
+			}
+			if retry != tt.wantRetry {
+				t.Errorf("retry = %v, quer %v", retry, tt.wantRetry)
+			}
+			if !tt.wantNextAfter.IsZero() && !l.next.After(tt.wantNextAfter) {
+				t.Errorf("next = %v, quer depois de %v", l.next, tt.wantNextAfter)
+			}
+			if !tt.wantReset.IsZero() && !l.reset.Equal(tt.wantReset) {
+				t.Errorf("reset = %v, quer %v", l.reset, tt.wantReset)
+			}
+		})
+	}
+}
+
+func TestRateLimiterWaitSpendsRemaining(t *testing.T) {
+	l := NewRateLimiter()
+	l.limit = 1 << 20
+	l.Observe(rateLimitResponse(200, map[string]string{
+		"X-RateLimit-Remaining": "2",
+		"X-RateLimit-Reset":     strconv.FormatInt(time.Now().Add(time.Hour).Unix(), 10),
+	}))
+
+	// o saldo informado libera duas requisições sem esperar
+	for i := 0; i < 2; i++ {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		err := l.Wait(ctx)
+		cancel()
+		if err != nil {
+			t.Fatalf("Wait %d: %v", i+1, err)
+		}
+	}
+	if l.remaining != 0 {
+		t.Fatalf("remaining = %d, quer 0", l.remaining)
+	}
+
+	// a terceira espera o reset, mesmo sem nova resposta
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := l.Wait(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("err = %v, quer context.DeadlineExceeded", err)
+	}
+
+	// sem o header o saldo é desconhecido e não segura mais
+	l = NewRateLimiter()
+	l.limit = 1 << 20
+	l.Observe(rateLimitResponse(200, map[string]string{"X-RateLimit-Remaining": "0"}))
+	l.Observe(rateLimitResponse(200, nil))
+	ctx, cancel = context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := l.Wait(ctx); err != nil {
+		t.Errorf("Wait com saldo desconhecido: %v", err)
+	}
+}
@@ -0,0 +1,50 @@
+package logic
+
+import (
+	"context"
+
+	"github.com/gpt-utils/internal/dto"
+)
+
+// SeasonMedia é um anime listado na temporada, com os campos suficientes
+// para criar o documento; o resto vem depois pelo enrich-anilist.
+type SeasonMedia struct {
+	ID              int      `json:"id"`
+	IDMal           int      `json:"idMal"`
+	Title           Title    `json:"title"`
+	Synonyms        []string `json:"synonyms"`
+	Format          string   `json:"format"`
+	Status          string   `json:"status"`
+	Episodes        int      `json:"episodes"`
+	Duration        int      `json:"duration"`
+	Source          string   `json:"source"`
+	CountryOfOrigin string   `json:"countryOfOrigin"`
+	IsAdult         bool     `json:"isAdult"`
+	Genres          []string `json:"genres"`
+	Description     string   `json:"description"`
+	StartDate       struct {
+		Day   int `json:"day"`
+		Month int `json:"month"`
+		Year  int `json:"year"`
+	} `json:"startDate"`
+	EndDate struct {
+		Day   int `json:"day"`
+		Month int `json:"month"`
+		Year  int `json:"year"`
+	} `json:"endDate"`
+}
+
+// FetchSeasonPage busca uma página dos animes da temporada, ordenados por
+// popularidade. hasNext indica se há mais páginas.
+func (c *AniListClient) FetchSeasonPage(ctx context.Context, season dto.AnimeSeason, page, perPage int) (media []SeasonMedia, hasNext bool, err error) {
+	data, err := execute[pageData[SeasonMedia]](ctx, c, seasonPageQuery, map[string]interface{}{
+		"season":     season.Season,
+		"seasonYear": season.Year,
+		"page":       page,
+		"perPage":    perPage,
+	})
+	if err != nil {
+		return nil, false, err
+	}
+	return data.Page.Media, data.Page.PageInfo.HasNextPage, nil
+}
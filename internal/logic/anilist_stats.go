@@ -0,0 +1,50 @@
+package logic
+
+import "context"
+
+// MediaRanking é uma posição da mídia em um ranking da AniList.
+type MediaRanking struct {
+	Rank    int    `json:"rank"`
+	Type    string `json:"type"`
+	Context string `json:"context"`
+	AllTime bool   `json:"allTime"`
+	Season  string `json:"season"`
+	Year    int    `json:"year"`
+}
+
+// MediaStats são as estatísticas da AniList que mudam com o tempo e vão
+// para os snapshots de anime_stats.
+type MediaStats struct {
+	ID           int            `json:"id"`
+	AverageScore int            `json:"averageScore"`
+	MeanScore    int            `json:"meanScore"`
+	Popularity   int            `json:"popularity"`
+	Favourites   int            `json:"favourites"`
+	Trending     int            `json:"trending"`
+	Rankings     []MediaRanking `json:"rankings"`
+}
+
+// Stats devolve as estatísticas de uma mídia já buscada com media.graphql.
+func (m MediaDetail) Stats() MediaStats {
+	stats := MediaStats{
+		ID:           m.ID,
+		AverageScore: m.AverageScore,
+		MeanScore:    m.MeanScore,
+		Popularity:   m.Popularity,
+		Favourites:   m.Favourites,
+		Trending:     m.Trending,
+	}
+	for _, rk := range m.Rankings {
+		stats.Rankings = append(stats.Rankings, MediaRanking(rk))
+	}
+	return stats
+}
+
+// FetchStatsByID busca só as estatísticas do anime, sem o resto da mídia.
+func (c *AniListClient) FetchStatsByID(ctx context.Context, id int) (*MediaStats, error) {
+	data, err := execute[mediaData[MediaStats]](ctx, c, statsQuery, mediaLookup{id: id}.variables())
+	if err != nil {
+		return nil, err
+	}
+	return &data.Media, nil
+}
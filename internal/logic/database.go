@@ -9,30 +9,24 @@ import (
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
-var Client *mongo.Client
-
-func Connect(uri string) {
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+// Connect abre uma conexão com o MongoDB e testa com um ping.
+func Connect(ctx context.Context, uri string) (*mongo.Client, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
 	defer cancel()
 
 	clientOptions := options.Client().ApplyURI(uri)
 
 	client, err := mongo.Connect(ctx, clientOptions)
 	if err != nil {
-		log.Fatal(err)
+		return nil, err
 	}
 
 	// Testa conexão
-	err = client.Ping(ctx, nil)
-	if err != nil {
-		log.Fatal(err)
+	if err := client.Ping(ctx, nil); err != nil {
+		client.Disconnect(context.Background())
+		return nil, err
 	}
 
-	Client = client
 	log.Println("Conectado ao MongoDB!")
-}
-
-// GetDB returns the MongoDB client instance
-func GetDB() *mongo.Client {
-	return Client
+	return client, nil
 }
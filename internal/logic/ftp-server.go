@@ -10,6 +10,12 @@ import (
 	"strings"
 )
 
+type FtpConfig struct {
+	Addr     string
+	User     string
+	Password string
+}
+
 type FtpClient struct {
 	conn   net.Conn
 	reader *bufio.Reader
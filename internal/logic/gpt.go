@@ -2,26 +2,41 @@ package logic
 
 import (
 	"fmt"
+	"net/http"
 )
 
+const openAIURL = "https://api.openai.com/v1/responses"
+
 type OpenAIRequest struct {
 	Model string `json:"model"`
 	Input string `json:"input"`
 }
 
-func CallOpenAI(apiKey, model, input string) ([]byte, error) {
-	url := "https://api.openai.com/v1/responses"
+type OpenAIClient struct {
+	HTTP   *http.Client
+	URL    string
+	APIKey string
+}
+
+func NewOpenAIClient(httpClient *http.Client, apiKey string) *OpenAIClient {
+	return &OpenAIClient{
+		HTTP:   httpClient,
+		URL:    openAIURL,
+		APIKey: apiKey,
+	}
+}
 
+func (c *OpenAIClient) CallOpenAI(model, input string) ([]byte, error) {
 	payload := OpenAIRequest{
 		Model: model,
 		Input: input,
 	}
 
 	headers := map[string]string{
-		"Authorization": "Bearer " + apiKey,
+		"Authorization": "Bearer " + c.APIKey,
 	}
 
-	body, err := HTTPPostWithHeaders(url, payload, headers)
+	body, err := HTTPPostWithClient(c.HTTP, c.URL, payload, headers)
 	if err != nil {
 		return nil, fmt.Errorf("erro ao chamar OpenAI: %w", err)
 	}
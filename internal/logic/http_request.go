@@ -10,6 +10,11 @@ import (
 )
 
 func HTTPPostWithHeaders(url string, payload interface{}, headers map[string]string) ([]byte, error) {
+	return HTTPPostWithClient(&http.Client{}, url, payload, headers)
+}
+
+// HTTPPostWithClient é como HTTPPostWithHeaders, mas usando o client informado
+func HTTPPostWithClient(client *http.Client, url string, payload interface{}, headers map[string]string) ([]byte, error) {
 	jsonData, err := json.Marshal(payload)
 	if err != nil {
 		return nil, fmt.Errorf("erro ao codificar JSON: %w", err)
@@ -25,7 +30,6 @@ func HTTPPostWithHeaders(url string, payload interface{}, headers map[string]str
 		req.Header.Set(key, value)
 	}
 
-	client := &http.Client{}
 	resp, err := client.Do(req)
 	if err != nil {
 		return nil, fmt.Errorf("erro ao enviar requisição: %w", err)
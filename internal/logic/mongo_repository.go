@@ -61,9 +61,10 @@ func (r *RepositoryMongo) UpdateOne(
 	ctx context.Context,
 	filter interface{},
 	update interface{},
+	opts ...*options.UpdateOptions,
 ) (modifiedCount int64, err error) {
 
-	result, err := r.Collection.UpdateOne(ctx, filter, update)
+	result, err := r.Collection.UpdateOne(ctx, filter, update, opts...)
 	if err != nil {
 		return 0, err
 	}
@@ -87,3 +88,32 @@ func (r *RepositoryMongo) Count(ctx context.Context, filter bson.M) (int, error)
 	count, err := r.Collection.CountDocuments(ctx, filter)
 	return int(count), err
 }
+
+// EnsureAniListIDIndex cria o índice único em aniListId. Sem ele, dois
+// upserts concorrentes do mesmo AniList ID (um por worker) inserem dois
+// documentos. Documentos ainda sem AniList ID ficam fora do índice.
+func (r *RepositoryMongo) EnsureAniListIDIndex(ctx context.Context) error {
+	_, err := r.Collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "aniListId", Value: 1}},
+		Options: options.Index().
+			SetUnique(true).
+			SetPartialFilterExpression(bson.M{"aniListId": bson.M{"$gt": 0}}),
+	})
+	return err
+}
+
+// upsertRetries é quantas vezes bulkUpsert repete a escrita depois de um
+// erro de chave duplicada.
+const upsertRetries = 3
+
+// bulkUpsert executa upserts por aniListId. Quando outro worker insere o
+// mesmo documento entre a busca e a inserção, o índice único devolve E11000;
+// na nova tentativa o documento já existe e o upsert vira update.
+func (r *RepositoryMongo) bulkUpsert(ctx context.Context, models []mongo.WriteModel) error {
+	opts := options.BulkWrite().SetOrdered(false)
+	_, err := r.Collection.BulkWrite(ctx, models, opts)
+	for i := 0; i < upsertRetries && mongo.IsDuplicateKeyError(err); i++ {
+		_, err = r.Collection.BulkWrite(ctx, models, opts)
+	}
+	return err
+}
@@ -5,7 +5,9 @@ import (
 
 	"github.com/gpt-utils/internal/dto"
 	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 func NewQueryAnimeMongo(collection *mongo.Collection) *RepositoryMongo {
@@ -45,3 +47,247 @@ func (r *RepositoryMongo) ListPageAnime(ctx context.Context, page int, pageSize
 	}
 	return animes, cursor.Err()
 }
+
+// ListAnimesAfter lista até pageSize animes que casam com query, ordenados por _id
+// e com _id maior que after. Um after zero começa do início da coleção.
+func (r *RepositoryMongo) ListAnimesAfter(ctx context.Context, after primitive.ObjectID, pageSize int, query bson.M) ([]dto.Anime, error) {
+	match := query
+	if !after.IsZero() {
+		match = bson.M{"$and": bson.A{query, bson.M{"_id": bson.M{"$gt": after}}}}
+	}
+
+	opts := options.Find().
+		SetSort(bson.D{{Key: "_id", Value: 1}}).
+		SetLimit(int64(pageSize))
+
+	cursor, err := r.Collection.Find(ctx, match, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var animes []dto.Anime
+	if err := cursor.All(ctx, &animes); err != nil {
+		return nil, err
+	}
+	return animes, nil
+}
+
+// FindIDsByAniListID devolve o _id dos animes do catálogo para cada AniList ID encontrado.
+func (r *RepositoryMongo) FindIDsByAniListID(ctx context.Context, aniListIDs []int) (map[int]primitive.ObjectID, error) {
+	ids := make(map[int]primitive.ObjectID)
+	if len(aniListIDs) == 0 {
+		return ids, nil
+	}
+
+	opts := options.Find().SetProjection(bson.M{"_id": 1, "aniListId": 1})
+	cursor, err := r.Collection.Find(ctx, bson.M{"aniListId": bson.M{"$in": aniListIDs}}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		var doc struct {
+			ID        primitive.ObjectID `bson:"_id"`
+			AniListID int                `bson:"aniListId"`
+		}
+		if err := cursor.Decode(&doc); err != nil {
+			return nil, err
+		}
+		ids[doc.AniListID] = doc.ID
+	}
+	return ids, cursor.Err()
+}
+
+// ListAnimeRelations lista os animes com AniList ID que casam com query,
+// trazendo só os campos usados para montar o grafo de franquias.
+func (r *RepositoryMongo) ListAnimeRelations(ctx context.Context, query bson.M) ([]dto.Anime, error) {
+	match := bson.M{"$and": bson.A{query, bson.M{"aniListId": bson.M{"$gt": 0}}}}
+	opts := options.Find().SetProjection(bson.M{
+		"_id":       1,
+		"aniListId": 1,
+		"title":     1,
+		"format":    1,
+		"startDate": 1,
+		"relations": 1,
+		"franchise": 1,
+	})
+
+	cursor, err := r.Collection.Find(ctx, match, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var animes []dto.Anime
+	if err := cursor.All(ctx, &animes); err != nil {
+		return nil, err
+	}
+	return animes, nil
+}
+
+// FindPersonRoles lista as participações da pessoa nos animes do catálogo:
+// papéis na staff e personagens dublados.
+func (r *RepositoryMongo) FindPersonRoles(ctx context.Context, personID primitive.ObjectID) ([]dto.PersonRole, error) {
+	filter := bson.M{"$or": bson.A{
+		bson.M{"staffs.personId": personID},
+		bson.M{"characters.voiceActors.personId": personID},
+	}}
+	opts := options.Find().
+		SetProjection(bson.M{"_id": 1, "title": 1, "staffs": 1, "characters.name": 1, "characters.voiceActors": 1}).
+		SetSort(bson.D{{Key: "title", Value: 1}})
+
+	cursor, err := r.Collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var roles []dto.PersonRole
+	for cursor.Next(ctx) {
+		var anime dto.Anime
+		if err := cursor.Decode(&anime); err != nil {
+			return nil, err
+		}
+		for _, s := range anime.Staffs {
+			if s.PersonID == nil || *s.PersonID != personID {
+				continue
+			}
+			for _, role := range s.Roles {
+				roles = append(roles, dto.PersonRole{AnimeID: anime.ID, AnimeTitle: anime.Title, Kind: dto.PersonRoleStaff, Role: role})
+			}
+		}
+		for _, c := range anime.Characters {
+			for _, va := range c.VoiceActors {
+				if va.PersonID == nil || *va.PersonID != personID {
+					continue
+				}
+				roles = append(roles, dto.PersonRole{AnimeID: anime.ID, AnimeTitle: anime.Title, Kind: dto.PersonRoleVoice, Role: va.LanguageV2, Character: c.Name})
+			}
+		}
+	}
+	return roles, cursor.Err()
+}
+
+// FindAnimesByStudio lista os animes em que o estúdio participa; com
+// mainOnly, só aqueles em que ele é o estúdio principal.
+func (r *RepositoryMongo) FindAnimesByStudio(ctx context.Context, studioID primitive.ObjectID, mainOnly bool) ([]dto.Anime, error) {
+	filter := bson.M{"studios.studioId": studioID}
+	if mainOnly {
+		filter = bson.M{"mainStudio.studioId": studioID}
+	}
+	opts := options.Find().
+		SetProjection(bson.M{"_id": 1, "title": 1, "format": 1, "startDate": 1, "mainStudio": 1}).
+		SetSort(bson.D{{Key: "startDate.year", Value: 1}, {Key: "title", Value: 1}})
+
+	cursor, err := r.Collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var animes []dto.Anime
+	if err := cursor.All(ctx, &animes); err != nil {
+		return nil, err
+	}
+	return animes, nil
+}
+
+// legacyAnimeKeys liga as chaves que o driver gerava para dto.Anime antes
+// das tags bson (o nome do campo em minúsculas) às chaves atuais. Só entram
+// os campos cujo nome muda; format, source, duration, studios e staffs já
+// coincidiam.
+var legacyAnimeKeys = map[string]string{
+	"startdate":         "startDate",
+	"enddate":           "endDate",
+	"chatgpt":           "chatGpt",
+	"chatgptdontfound":  "chatGptDontFound",
+	"averagescore":      "averageScore",
+	"countryoforigin":   "countryOfOrigin",
+	"isadult":           "isAdult",
+	"anilistapi":        "aniListApi",
+	"anilistnotfound":   "aniListNotFound",
+	"streamingepisodes": "streamingEpisodes",
+}
+
+// legacyCharacterKeys é o mesmo para os elementos de characters, que o
+// enrich-anilist antigo gravava a partir do struct dto.Character.
+var legacyCharacterKeys = map[string]string{
+	"dateofbirth": "dateOfBirth",
+	"anilistapi":  "aniListApi",
+	"voiceactors": "voiceActors",
+}
+
+// LegacyAnimeKeysFilter seleciona os animes que ainda têm alguma chave de
+// legacyAnimeKeys ou legacyCharacterKeys.
+func LegacyAnimeKeysFilter() bson.M {
+	var or bson.A
+	for old := range legacyAnimeKeys {
+		or = append(or, bson.M{old: bson.M{"$exists": true}})
+	}
+	return bson.M{"$or": append(or, legacyCharacterKeysFilter())}
+}
+
+func legacyCharacterKeysFilter() bson.M {
+	var or bson.A
+	for old := range legacyCharacterKeys {
+		or = append(or, bson.M{old: bson.M{"$exists": true}})
+	}
+	return bson.M{"characters": bson.M{"$elemMatch": bson.M{"$or": or}}}
+}
+
+// MigrateLegacyAnimeKeys renomeia as chaves de legacyAnimeKeys e
+// legacyCharacterKeys para as atuais e devolve quantas atualizações de
+// documento foram feitas. Quando as duas chaves existem vale a atual, que é
+// a que o enrich-anilist grava com $set; a antiga é removida.
+func (r *RepositoryMongo) MigrateLegacyAnimeKeys(ctx context.Context) (int64, error) {
+	var total int64
+	for old, key := range legacyAnimeKeys {
+		_, renamed, err := r.UpdateMany(ctx,
+			bson.M{old: bson.M{"$exists": true}, key: bson.M{"$exists": false}},
+			bson.M{"$rename": bson.M{old: key}})
+		if err != nil {
+			return total, err
+		}
+		_, removed, err := r.UpdateMany(ctx,
+			bson.M{old: bson.M{"$exists": true}},
+			bson.M{"$unset": bson.M{old: ""}})
+		if err != nil {
+			return total, err
+		}
+		total += renamed + removed
+	}
+
+	// cada elemento vira uma lista de pares k/v: as chaves antigas com par
+	// atual no mesmo elemento são descartadas e as demais são renomeadas
+	var drop, rename bson.A
+	for old, key := range legacyCharacterKeys {
+		drop = append(drop, bson.M{"$and": bson.A{
+			bson.M{"$eq": bson.A{"$$f.k", old}},
+			bson.M{"$ne": bson.A{bson.M{"$type": "$$c." + key}, "missing"}},
+		}})
+		rename = append(rename, bson.M{"case": bson.M{"$eq": bson.A{"$$f.k", old}}, "then": key})
+	}
+	element := bson.M{"$arrayToObject": bson.M{"$map": bson.M{
+		"input": bson.M{"$filter": bson.M{
+			"input": bson.M{"$objectToArray": "$$c"},
+			"as":    "f",
+			"cond":  bson.M{"$not": bson.A{bson.M{"$or": drop}}},
+		}},
+		"as": "f",
+		"in": bson.M{
+			"k": bson.M{"$switch": bson.M{"branches": rename, "default": "$$f.k"}},
+			"v": "$$f.v",
+		},
+	}}}
+	pipeline := mongo.Pipeline{{{Key: "$set", Value: bson.M{
+		"characters": bson.M{"$map": bson.M{"input": "$characters", "as": "c", "in": element}},
+	}}}}
+
+	_, migrated, err := r.UpdateMany(ctx, legacyCharacterKeysFilter(), pipeline)
+	if err != nil {
+		return total, err
+	}
+	return total + migrated, nil
+}
@@ -0,0 +1,51 @@
+package logic
+
+import (
+	"context"
+	"time"
+
+	"github.com/gpt-utils/internal/dto"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func NewQueryJobRunMongo(collection *mongo.Collection) *RepositoryMongo {
+	return NewRepositoryMongo(collection, func() dto.Document {
+		return &dto.JobRun{}
+	})
+}
+
+func (r *RepositoryMongo) CreateJobRun(ctx context.Context, run *dto.JobRun) error {
+	return r.InsertOne(ctx, run)
+}
+
+func (r *RepositoryMongo) FindJobRun(ctx context.Context, id string) (*dto.JobRun, error) {
+	var run dto.JobRun
+	if err := r.Collection.FindOne(ctx, bson.M{"_id": id}).Decode(&run); err != nil {
+		return nil, err
+	}
+	return &run, nil
+}
+
+// RecordJobOutcome avança o checkpoint da execução para lastID e contabiliza o resultado.
+func (r *RepositoryMongo) RecordJobOutcome(ctx context.Context, runID string, lastID primitive.ObjectID, outcome dto.JobOutcome) error {
+	update := bson.M{
+		"$set": bson.M{"lastId": lastID, "updatedAt": time.Now()},
+		"$inc": bson.M{"processed": 1, "counts." + outcome.Outcome: 1},
+	}
+	if outcome.Outcome != dto.OutcomeSuccess {
+		update["$push"] = bson.M{"outcomes": outcome}
+	}
+	_, err := r.UpdateOne(ctx, bson.M{"_id": runID}, update)
+	return err
+}
+
+func (r *RepositoryMongo) SetJobRunStatus(ctx context.Context, runID, status, errMsg string) error {
+	set := bson.M{"status": status, "error": errMsg, "updatedAt": time.Now()}
+	if status == dto.JobRunFinished {
+		set["finishedAt"] = time.Now()
+	}
+	_, err := r.UpdateOne(ctx, bson.M{"_id": runID}, bson.M{"$set": set})
+	return err
+}
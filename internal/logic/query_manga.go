@@ -0,0 +1,75 @@
+package logic
+
+import (
+	"context"
+	"time"
+
+	"github.com/gpt-utils/internal/dto"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+func NewQueryMangaMongo(collection *mongo.Collection) *RepositoryMongo {
+	return NewRepositoryMongo(collection, func() dto.Document {
+		return &dto.Manga{}
+	})
+}
+
+// ListMangasAfter é o ListAnimesAfter da coleção mangas.
+func (r *RepositoryMongo) ListMangasAfter(ctx context.Context, after primitive.ObjectID, pageSize int, query bson.M) ([]dto.Manga, error) {
+	match := query
+	if !after.IsZero() {
+		match = bson.M{"$and": bson.A{query, bson.M{"_id": bson.M{"$gt": after}}}}
+	}
+
+	opts := options.Find().
+		SetSort(bson.D{{Key: "_id", Value: 1}}).
+		SetLimit(int64(pageSize))
+
+	cursor, err := r.Collection.Find(ctx, match, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var mangas []dto.Manga
+	if err := cursor.All(ctx, &mangas); err != nil {
+		return nil, err
+	}
+	return mangas, nil
+}
+
+// UpsertMangaStubs cria, para cada relação de mangá que ainda não existe na
+// coleção, um documento mínimo (AniList ID e título) a ser completado pelo
+// enrich-manga. Devolve o _id de todos os mangás informados.
+func (r *RepositoryMongo) UpsertMangaStubs(ctx context.Context, relations []dto.Relation) (map[int]primitive.ObjectID, error) {
+	ids := make(map[int]primitive.ObjectID, len(relations))
+	if len(relations) == 0 {
+		return ids, nil
+	}
+
+	now := time.Now()
+	models := make([]mongo.WriteModel, 0, len(relations))
+	aniListIDs := make([]int, 0, len(relations))
+	for _, rel := range relations {
+		models = append(models, mongo.NewUpdateOneModel().
+			SetFilter(bson.M{"aniListId": rel.AniListID}).
+			SetUpdate(bson.M{"$setOnInsert": bson.M{
+				"title":      rel.Title,
+				"format":     rel.Format,
+				"startDate":  rel.StartDate,
+				"aniListApi": false,
+				"createdAt":  now,
+				"updatedAt":  now,
+			}}).
+			SetUpsert(true))
+		aniListIDs = append(aniListIDs, rel.AniListID)
+	}
+
+	if err := r.bulkUpsert(ctx, models); err != nil {
+		return nil, err
+	}
+	return r.FindIDsByAniListID(ctx, aniListIDs)
+}
@@ -0,0 +1,74 @@
+package logic
+
+import (
+	"context"
+	"time"
+
+	"github.com/gpt-utils/internal/dto"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+func NewQueryMatchReviewMongo(collection *mongo.Collection) *RepositoryMongo {
+	return NewRepositoryMongo(collection, func() dto.Document {
+		return &dto.MatchReview{}
+	})
+}
+
+// UpsertPendingReview cria a revisão do anime ou atualiza os candidatos da
+// revisão pendente que já existir, para não acumular revisões duplicadas.
+func (r *RepositoryMongo) UpsertPendingReview(ctx context.Context, review dto.MatchReview) error {
+	filter := bson.M{"animeId": review.AnimeID, "status": dto.ReviewPending}
+	update := bson.M{
+		"$set": bson.M{
+			"title":      review.Title,
+			"synonyms":   review.Synonyms,
+			"format":     review.Format,
+			"episodes":   review.Episodes,
+			"startYear":  review.StartYear,
+			"reason":     review.Reason,
+			"candidates": review.Candidates,
+		},
+		"$setOnInsert": bson.M{"createdAt": time.Now()},
+	}
+	_, err := r.Collection.UpdateOne(ctx, filter, update, options.Update().SetUpsert(true))
+	return err
+}
+
+func (r *RepositoryMongo) ListReviews(ctx context.Context, status string, limit int) ([]dto.MatchReview, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "createdAt", Value: 1}})
+	if limit > 0 {
+		opts.SetLimit(int64(limit))
+	}
+
+	cursor, err := r.Collection.Find(ctx, bson.M{"status": status}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var reviews []dto.MatchReview
+	if err := cursor.All(ctx, &reviews); err != nil {
+		return nil, err
+	}
+	return reviews, nil
+}
+
+func (r *RepositoryMongo) FindReview(ctx context.Context, id primitive.ObjectID) (*dto.MatchReview, error) {
+	var review dto.MatchReview
+	if err := r.Collection.FindOne(ctx, bson.M{"_id": id}).Decode(&review); err != nil {
+		return nil, err
+	}
+	return &review, nil
+}
+
+func (r *RepositoryMongo) ResolveReview(ctx context.Context, id primitive.ObjectID, status string, acceptedID int) error {
+	set := bson.M{"status": status, "resolvedAt": time.Now()}
+	if acceptedID != 0 {
+		set["acceptedId"] = acceptedID
+	}
+	_, err := r.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": set})
+	return err
+}
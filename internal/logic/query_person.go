@@ -0,0 +1,106 @@
+package logic
+
+import (
+	"context"
+	"time"
+
+	"github.com/gpt-utils/internal/dto"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+func NewQueryPersonMongo(collection *mongo.Collection) *RepositoryMongo {
+	return NewRepositoryMongo(collection, func() dto.Document {
+		return &dto.Person{}
+	})
+}
+
+// UpsertPeople grava as pessoas pelo AniList ID, atualizando as que já
+// existem, e devolve o _id de cada uma.
+func (r *RepositoryMongo) UpsertPeople(ctx context.Context, people []dto.Person) (map[int]primitive.ObjectID, error) {
+	ids := make(map[int]primitive.ObjectID, len(people))
+	if len(people) == 0 {
+		return ids, nil
+	}
+
+	now := time.Now()
+	models := make([]mongo.WriteModel, 0, len(people))
+	aniListIDs := make([]int, 0, len(people))
+	for _, p := range people {
+		set := bson.M{
+			"name":      p.Name,
+			"updatedAt": now,
+		}
+		// cada consulta traz campos diferentes da pessoa (a staff não traz
+		// idioma nem datas, por exemplo); só grava os que vieram, para não
+		// apagar o que outra consulta já salvou
+		optional := map[string]string{
+			"nativeName": p.NativeName,
+			"image":      p.Image,
+			"siteUrl":    p.SiteUrl,
+			"homeTown":   p.HomeTown,
+			"gender":     p.Gender,
+			"languageV2": p.LanguageV2,
+		}
+		for key, value := range optional {
+			if value != "" {
+				set[key] = value
+			}
+		}
+		if p.Age != 0 {
+			set["age"] = p.Age
+		}
+		if p.DateOfBirth != (dto.DateOfBirth{}) {
+			set["dateOfBirth"] = p.DateOfBirth
+		}
+		if p.DateOfDeath != (dto.DateOfBirth{}) {
+			set["dateOfDeath"] = p.DateOfDeath
+		}
+		models = append(models, mongo.NewUpdateOneModel().
+			SetFilter(bson.M{"aniListId": p.AniListID}).
+			SetUpdate(bson.M{"$set": set, "$setOnInsert": bson.M{"createdAt": now}}).
+			SetUpsert(true))
+		aniListIDs = append(aniListIDs, p.AniListID)
+	}
+
+	if err := r.bulkUpsert(ctx, models); err != nil {
+		return nil, err
+	}
+
+	opts := options.Find().SetProjection(bson.M{"_id": 1, "aniListId": 1})
+	cursor, err := r.Collection.Find(ctx, bson.M{"aniListId": bson.M{"$in": aniListIDs}}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		var doc struct {
+			ID        primitive.ObjectID `bson:"_id"`
+			AniListID int                `bson:"aniListId"`
+		}
+		if err := cursor.Decode(&doc); err != nil {
+			return nil, err
+		}
+		ids[doc.AniListID] = doc.ID
+	}
+	return ids, cursor.Err()
+}
+
+func (r *RepositoryMongo) FindPersonByAniListID(ctx context.Context, aniListID int) (*dto.Person, error) {
+	var person dto.Person
+	if err := r.Collection.FindOne(ctx, bson.M{"aniListId": aniListID}).Decode(&person); err != nil {
+		return nil, err
+	}
+	return &person, nil
+}
+
+func (r *RepositoryMongo) FindPerson(ctx context.Context, id primitive.ObjectID) (*dto.Person, error) {
+	var person dto.Person
+	if err := r.Collection.FindOne(ctx, bson.M{"_id": id}).Decode(&person); err != nil {
+		return nil, err
+	}
+	return &person, nil
+}
@@ -0,0 +1,67 @@
+package logic
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/gpt-utils/internal/dto"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+func NewQueryStatsMongo(collection *mongo.Collection) *RepositoryMongo {
+	return NewRepositoryMongo(collection, func() dto.Document {
+		return &dto.StatsSnapshot{}
+	})
+}
+
+// EnsureStatsCollection cria a coleção de série temporal das estatísticas,
+// se ainda não existir.
+func EnsureStatsCollection(ctx context.Context, db *mongo.Database, name string) error {
+	opts := options.CreateCollection().SetTimeSeriesOptions(
+		options.TimeSeries().
+			SetTimeField("takenAt").
+			SetMetaField("animeId").
+			SetGranularity("hours"),
+	)
+	err := db.CreateCollection(ctx, name, opts)
+	var cmdErr mongo.CommandError
+	if errors.As(err, &cmdErr) && cmdErr.Name == "NamespaceExists" {
+		return nil
+	}
+	return err
+}
+
+// EnsureTimeSeries é o EnsureStatsCollection da coleção do repositório.
+func (r *RepositoryMongo) EnsureTimeSeries(ctx context.Context) error {
+	return EnsureStatsCollection(ctx, r.Collection.Database(), r.Collection.Name())
+}
+
+func (r *RepositoryMongo) InsertSnapshot(ctx context.Context, snapshot dto.StatsSnapshot) error {
+	_, err := r.Collection.InsertOne(ctx, snapshot)
+	return err
+}
+
+// LatestSnapshots devolve, para cada anime, a última leitura feita até at.
+func (r *RepositoryMongo) LatestSnapshots(ctx context.Context, at time.Time) ([]dto.StatsSnapshot, error) {
+	pipeline := mongo.Pipeline{
+		bson.D{{Key: "$match", Value: bson.M{"takenAt": bson.M{"$lte": at}}}},
+		bson.D{{Key: "$sort", Value: bson.D{{Key: "takenAt", Value: 1}}}},
+		bson.D{{Key: "$group", Value: bson.M{"_id": "$animeId", "snapshot": bson.M{"$last": "$$ROOT"}}}},
+		bson.D{{Key: "$replaceRoot", Value: bson.M{"newRoot": "$snapshot"}}},
+	}
+
+	cursor, err := r.Collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var snapshots []dto.StatsSnapshot
+	if err := cursor.All(ctx, &snapshots); err != nil {
+		return nil, err
+	}
+	return snapshots, nil
+}
@@ -0,0 +1,93 @@
+package logic
+
+import (
+	"context"
+	"regexp"
+	"time"
+
+	"github.com/gpt-utils/internal/dto"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+func NewQueryStudioMongo(collection *mongo.Collection) *RepositoryMongo {
+	return NewRepositoryMongo(collection, func() dto.Document {
+		return &dto.StudioDocument{}
+	})
+}
+
+// UpsertStudios grava os estúdios pelo AniList ID e devolve o _id de cada um.
+func (r *RepositoryMongo) UpsertStudios(ctx context.Context, studios []dto.StudioDocument) (map[int]primitive.ObjectID, error) {
+	ids := make(map[int]primitive.ObjectID, len(studios))
+	if len(studios) == 0 {
+		return ids, nil
+	}
+
+	now := time.Now()
+	models := make([]mongo.WriteModel, 0, len(studios))
+	aniListIDs := make([]int, 0, len(studios))
+	for _, s := range studios {
+		models = append(models, mongo.NewUpdateOneModel().
+			SetFilter(bson.M{"aniListId": s.AniListID}).
+			SetUpdate(bson.M{
+				"$set": bson.M{
+					"name":              s.Name,
+					"siteUrl":           s.SiteUrl,
+					"isAnimationStudio": s.IsAnimationStudio,
+					"updatedAt":         now,
+				},
+				"$setOnInsert": bson.M{"createdAt": now},
+			}).
+			SetUpsert(true))
+		aniListIDs = append(aniListIDs, s.AniListID)
+	}
+
+	if err := r.bulkUpsert(ctx, models); err != nil {
+		return nil, err
+	}
+
+	opts := options.Find().SetProjection(bson.M{"_id": 1, "aniListId": 1})
+	cursor, err := r.Collection.Find(ctx, bson.M{"aniListId": bson.M{"$in": aniListIDs}}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		var doc struct {
+			ID        primitive.ObjectID `bson:"_id"`
+			AniListID int                `bson:"aniListId"`
+		}
+		if err := cursor.Decode(&doc); err != nil {
+			return nil, err
+		}
+		ids[doc.AniListID] = doc.ID
+	}
+	return ids, cursor.Err()
+}
+
+func (r *RepositoryMongo) FindStudioByAniListID(ctx context.Context, aniListID int) (*dto.StudioDocument, error) {
+	var studio dto.StudioDocument
+	if err := r.Collection.FindOne(ctx, bson.M{"aniListId": aniListID}).Decode(&studio); err != nil {
+		return nil, err
+	}
+	return &studio, nil
+}
+
+// FindStudiosByName busca estúdios cujo nome contém name, sem diferenciar maiúsculas.
+func (r *RepositoryMongo) FindStudiosByName(ctx context.Context, name string) ([]dto.StudioDocument, error) {
+	filter := bson.M{"name": primitive.Regex{Pattern: regexp.QuoteMeta(name), Options: "i"}}
+	cursor, err := r.Collection.Find(ctx, filter, options.Find().SetSort(bson.D{{Key: "name", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var studios []dto.StudioDocument
+	if err := cursor.All(ctx, &studios); err != nil {
+		return nil, err
+	}
+	return studios, nil
+}
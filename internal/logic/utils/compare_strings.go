@@ -18,3 +18,94 @@ func CompareFirstWords(a, b string) bool {
 	// compara ignorando maiúsculas/minúsculas
 	return strings.EqualFold(ma[1], mb[1])
 }
+
+var nonAlphanumeric = regexp.MustCompile(`[^\p{L}\p{N}]+`)
+
+// NormalizeTitle deixa o texto em minúsculas, troca pontuação por espaço e
+// remove espaços repetidos, para comparar títulos escritos de formas diferentes.
+func NormalizeTitle(s string) string {
+	s = strings.ToLower(s)
+	s = nonAlphanumeric.ReplaceAllString(s, " ")
+	return strings.TrimSpace(s)
+}
+
+// Similarity devolve de 0 a 1 o quanto a e b se parecem, usando o
+// coeficiente de Dice sobre os bigramas de caracteres dos textos normalizados.
+func Similarity(a, b string) float64 {
+	a, b = NormalizeTitle(a), NormalizeTitle(b)
+	if a == "" || b == "" {
+		return 0
+	}
+	if a == b {
+		return 1
+	}
+
+	ba, bb := bigrams(a), bigrams(b)
+	if len(ba) == 0 || len(bb) == 0 {
+		return 0
+	}
+
+	counts := make(map[string]int, len(ba))
+	for _, g := range ba {
+		counts[g]++
+	}
+	var shared int
+	for _, g := range bb {
+		if counts[g] > 0 {
+			counts[g]--
+			shared++
+		}
+	}
+	return 2 * float64(shared) / float64(len(ba)+len(bb))
+}
+
+func bigrams(s string) []string {
+	r := []rune(s)
+	if len(r) < 2 {
+		return nil
+	}
+	out := make([]string, 0, len(r)-1)
+	for i := 0; i < len(r)-1; i++ {
+		out = append(out, string(r[i:i+2]))
+	}
+	return out
+}
+
+// NameSimilarity compara nomes de pessoas/personagens de 0 a 1. Compara as
+// palavras normalizadas, sem importar a ordem ("Uzumaki Naruto" = "Naruto
+// Uzumaki"): nomes com as mesmas palavras valem 1 e, quando falta uma única
+// palavra em um deles ("Naruto" e "Naruto Uzumaki"), 0.9. Qualquer palavra
+// diferente dá 0, para que parentes com o mesmo sobrenome ("Naruto
+// Uzumaki" e "Boruto Uzumaki") não sejam confundidos.
+func NameSimilarity(a, b string) float64 {
+	ta, tb := nameTokens(a), nameTokens(b)
+	if len(ta) == 0 || len(tb) == 0 {
+		return 0
+	}
+
+	small, large := ta, tb
+	if len(small) > len(large) {
+		small, large = large, small
+	}
+	for t := range small {
+		if !large[t] {
+			return 0
+		}
+	}
+
+	switch len(large) - len(small) {
+	case 0:
+		return 1
+	case 1:
+		return 0.9
+	}
+	return 0
+}
+
+func nameTokens(s string) map[string]bool {
+	tokens := make(map[string]bool)
+	for _, t := range strings.Fields(NormalizeTitle(s)) {
+		tokens[t] = true
+	}
+	return tokens
+}
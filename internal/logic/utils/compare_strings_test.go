@@ -0,0 +1,64 @@
+package utils
+
+import (
+	"math"
+	"testing"
+)
+
+func TestSimilarity(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want float64
+	}{
+		{"Naruto: Shippuden", "naruto shippuden", 1},
+		{"", "Naruto", 0},
+		{"!!!", "Naruto", 0},
+		{"a", "b", 0},
+		{"night", "nacht", 0.25},
+		{"Shingeki no Kyojin", "Shingeki no Kyojin Season 2", 2 * 17.0 / float64(17+26)},
+	}
+	for _, tt := range tests {
+		if got := Similarity(tt.a, tt.b); math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("Similarity(%q, %q) = %v, quer %v", tt.a, tt.b, got, tt.want)
+		}
+		if got, rev := Similarity(tt.a, tt.b), Similarity(tt.b, tt.a); got != rev {
+			t.Errorf("Similarity não é simétrica para %q e %q: %v x %v", tt.a, tt.b, got, rev)
+		}
+	}
+}
+
+// nameMatchThreshold é o characterMatchThreshold de scripts: a partir
+// dessa nota um personagem salvo é considerado o mesmo da AniList.
+const nameMatchThreshold = 0.9
+
+func TestNameSimilarity(t *testing.T) {
+	tests := []struct {
+		a, b  string
+		want  float64
+		match bool
+	}{
+		{"Uzumaki Naruto", "Naruto Uzumaki", 1, true},
+		{"Akame", "akame", 1, true},
+		// falta uma palavra em um dos nomes
+		{"Naruto", "Naruto Uzumaki", 0.9, true},
+		{"Levi", "Levi Ackerman", 0.9, true},
+		// falta mais de uma palavra
+		{"Naruto", "Naruto's Father", 0, false},
+		// parentes com o mesmo sobrenome
+		{"Naruto Uzumaki", "Boruto Uzumaki", 0, false},
+		{"Mikasa Ackerman", "Levi Ackerman", 0, false},
+		// grafias diferentes não casam
+		{"Sasuke Uchiha", "Sasuke Uchia", 0, false},
+		{"Eren Jaeger", "Eren Yeager", 0, false},
+		{"", "Naruto", 0, false},
+	}
+	for _, tt := range tests {
+		got := NameSimilarity(tt.a, tt.b)
+		if math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("NameSimilarity(%q, %q) = %v, quer %v", tt.a, tt.b, got, tt.want)
+		}
+		if match := got >= nameMatchThreshold; match != tt.match {
+			t.Errorf("NameSimilarity(%q, %q) = %v: casa = %v, quer %v", tt.a, tt.b, got, match, tt.match)
+		}
+	}
+}
@@ -0,0 +1,88 @@
+package utils
+
+import (
+	"html"
+	"regexp"
+	"strings"
+)
+
+// RichText é um texto da AniList (descrição de mídia ou bio de personagem)
+// separado em partes: Text é texto puro, Markdown mantém negrito/itálico
+// sem nenhum HTML, Spoilers são os trechos ~!...!~ retirados do texto e
+// Source é a atribuição final, como "MAL Rewrite".
+type RichText struct {
+	Text     string
+	Markdown string
+	Spoilers []string
+	Source   string
+}
+
+var (
+	spoilerBlock  = regexp.MustCompile(`(?s)~!(.*?)!~`)
+	lineBreakTag  = regexp.MustCompile(`(?i)<br\s*/?>`)
+	italicTag     = regexp.MustCompile(`(?is)<(i|em)>(.*?)</(i|em)>`)
+	boldTag       = regexp.MustCompile(`(?is)<(b|strong)>(.*?)</(b|strong)>`)
+	anyTag        = regexp.MustCompile(`(?s)<[^>]*>`)
+	sourceTrailer = regexp.MustCompile(`(?is)[\(\[]\s*(?:source|written by)\s*:?\s*([^\)\]]+?)\s*[\)\]]\s*$`)
+	boldMarkdown  = regexp.MustCompile(`__(.+?)__`)
+	linkMarkdown  = regexp.MustCompile(`\[([^\]]*)\]\([^\)]*\)`)
+	emphasis      = regexp.MustCompile(`\*\*(.+?)\*\*|\*(\S(?:.*?\S)?)\*|\b_(.+?)_\b`)
+	blankLines    = regexp.MustCompile(`\n{3,}`)
+	repeatedSpace = regexp.MustCompile(`[ \t]{2,}`)
+	markupEscaper = strings.NewReplacer("<", "&lt;", ">", "&gt;")
+)
+
+// CleanRichText converte o texto da AniList em RichText.
+func CleanRichText(s string) RichText {
+	var rt RichText
+
+	s = strings.ReplaceAll(s, "\r\n", "\n")
+	s = lineBreakTag.ReplaceAllString(s, "\n")
+
+	for _, m := range spoilerBlock.FindAllStringSubmatch(s, -1) {
+		if spoiler := cleanLines(stripMarkdown(stripTags(m[1]))); spoiler != "" {
+			rt.Spoilers = append(rt.Spoilers, spoiler)
+		}
+	}
+	s = spoilerBlock.ReplaceAllString(s, "")
+
+	s = italicTag.ReplaceAllString(s, "_${2}_")
+	s = boldTag.ReplaceAllString(s, "**${2}**")
+	s = stripTags(s)
+	s = boldMarkdown.ReplaceAllString(s, "**${1}**")
+	s = cleanLines(s)
+
+	if m := sourceTrailer.FindStringSubmatchIndex(s); m != nil {
+		rt.Source = strings.TrimSpace(s[m[2]:m[3]])
+		s = cleanLines(s[:m[0]])
+	}
+
+	rt.Markdown = s
+	rt.Text = cleanLines(stripMarkdown(s))
+	return rt
+}
+
+// stripTags remove as tags HTML e decodifica as entidades. Um < ou >
+// que vinha escapado (&lt;script&gt;) continua escapado, para que o texto
+// nunca traga marcação viva.
+func stripTags(s string) string {
+	s = html.UnescapeString(anyTag.ReplaceAllString(s, ""))
+	return markupEscaper.Replace(s)
+}
+
+// stripMarkdown tira ênfase e links, mantendo só o texto.
+func stripMarkdown(s string) string {
+	s = linkMarkdown.ReplaceAllString(s, "$1")
+	s = boldMarkdown.ReplaceAllString(s, "$1")
+	return emphasis.ReplaceAllString(s, "$1$2$3")
+}
+
+// cleanLines remove espaços nas pontas de cada linha e junta linhas em branco repetidas.
+func cleanLines(s string) string {
+	lines := strings.Split(s, "\n")
+	for i, l := range lines {
+		lines[i] = strings.TrimSpace(repeatedSpace.ReplaceAllString(l, " "))
+	}
+	s = strings.Join(lines, "\n")
+	return strings.TrimSpace(blankLines.ReplaceAllString(s, "\n\n"))
+}
@@ -0,0 +1,96 @@
+package utils
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestCleanRichText(t *testing.T) {
+	tests := []struct {
+		name     string
+		in       string
+		text     string
+		markdown string
+		spoilers []string
+		source   string
+	}{
+		{
+			name:     "br vira quebra de linha",
+			in:       "First line.<br>Second line.<br /><br>\r\nThird line.",
+			text:     "First line.\nSecond line.\n\nThird line.",
+			markdown: "First line.\nSecond line.\n\nThird line.",
+		},
+		{
+			name:     "negrito com sublinhados",
+			in:       "__Height:__ 170 cm\n__Affiliations:__ Survey Corps",
+			text:     "Height: 170 cm\nAffiliations: Survey Corps",
+			markdown: "**Height:** 170 cm\n**Affiliations:** Survey Corps",
+		},
+		{
+			name:     "tags HTML viram markdown",
+			in:       "<i>Attack on Titan</i> is <b>popular</b> &amp; <a href=\"x\">long</a>.",
+			text:     "Attack on Titan is popular & long.",
+			markdown: "_Attack on Titan_ is **popular** & long.",
+		},
+		{
+			name:     "spoiler sai do texto",
+			in:       "Eren joins the Survey Corps. ~!He is the __Attack Titan__.!~ The end.",
+			text:     "Eren joins the Survey Corps. The end.",
+			markdown: "Eren joins the Survey Corps. The end.",
+			spoilers: []string{"He is the Attack Titan."},
+		},
+		{
+			name:     "fonte entre parênteses",
+			in:       "Humanity lives inside cities.<br><br>\n(Source: Crunchyroll)",
+			text:     "Humanity lives inside cities.",
+			markdown: "Humanity lives inside cities.",
+			source:   "Crunchyroll",
+		},
+		{
+			name:     "written by entre colchetes",
+			in:       "A boy and his dog.\n\n[Written by MAL Rewrite]",
+			text:     "A boy and his dog.",
+			markdown: "A boy and his dog.",
+			source:   "MAL Rewrite",
+		},
+		{
+			name:     "snake_case não é itálico",
+			in:       "The snake_case_name stays, _this_ does not.",
+			text:     "The snake_case_name stays, this does not.",
+			markdown: "The snake_case_name stays, _this_ does not.",
+		},
+		{
+			name:     "marcação escapada continua escapada",
+			in:       "A &lt;script&gt;alert(1)&lt;/script&gt; b",
+			text:     "A &lt;script&gt;alert(1)&lt;/script&gt; b",
+			markdown: "A &lt;script&gt;alert(1)&lt;/script&gt; b",
+		},
+		{
+			name:     "asterisco solto não é ênfase",
+			in:       "5 * 3 * 2 equals 30, *really*.",
+			text:     "5 * 3 * 2 equals 30, really.",
+			markdown: "5 * 3 * 2 equals 30, *really*.",
+		},
+		{
+			name: "vazio",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := CleanRichText(tt.in)
+			if got.Text != tt.text {
+				t.Errorf("Text = %q, quer %q", got.Text, tt.text)
+			}
+			if got.Markdown != tt.markdown {
+				t.Errorf("Markdown = %q, quer %q", got.Markdown, tt.markdown)
+			}
+			if !slices.Equal(got.Spoilers, tt.spoilers) {
+				t.Errorf("Spoilers = %q, quer %q", got.Spoilers, tt.spoilers)
+			}
+			if got.Source != tt.source {
+				t.Errorf("Source = %q, quer %q", got.Source, tt.source)
+			}
+		})
+	}
+}
@@ -0,0 +1,93 @@
+package scripts
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/gpt-utils/internal/dto"
+	"github.com/gpt-utils/internal/logic"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// RefreshAiring consulta de novo na AniList os animes em exibição ou
+// anunciados e atualiza só o que muda com o tempo: status, episódios,
+// data de término e a agenda dos próximos episódios.
+func RefreshAiring(ctx context.Context, r *Runner, opts Options) error {
+	return r.eachAnime(ctx, opts, func(anime dto.Anime) error {
+		info, err := r.AniList.FetchAiringByID(ctx, anime.AniListID)
+		if errors.Is(err, logic.ErrMediaNotFound) {
+			return r.markNotFound(ctx, opts, anime, bson.M{"aniListNotFound": true})
+		}
+		if err != nil {
+			return fmt.Errorf("falha ao buscar agenda de %q: %w", anime.Title, err)
+		}
+
+		episodes := info.Episodes
+		if episodes == 0 && info.NextAiringEpisode != nil {
+			// sem total definido, guarda quantos episódios já foram ao ar
+			episodes = info.NextAiringEpisode.Episode - 1
+		}
+		if episodes <= 0 {
+			episodes = anime.Episodes
+		}
+		next := nextAiringEpisode(info.NextAiringEpisode)
+		schedule := airingSchedule(info.AiringSchedule.Nodes)
+
+		changes := diffFields(
+			FieldChange{"status", anime.Status, info.Status},
+			FieldChange{"episodes", anime.Episodes, episodes},
+			FieldChange{"endDate", anime.EndDate, dto.EndDate(info.EndDate)},
+		)
+		// a agenda muda sozinha quando um episódio é adiado, sem mexer em status e episódios
+		if len(changes) == 0 && sameAiringEpisode(anime.NextAiringEpisode, next) && sameAiringSchedule(anime.AiringSchedule, schedule) {
+			return nil
+		}
+
+		set := bson.M{
+			"status":            info.Status,
+			"episodes":          episodes,
+			"endDate":           dto.EndDate(info.EndDate),
+			"nextAiringEpisode": next,
+			"airingSchedule":    schedule,
+		}
+
+		if _, err := r.updateOne(ctx, opts, bson.M{"_id": anime.ID}, bson.M{"$set": set}); err != nil {
+			return fmt.Errorf("erro ao atualizar agenda de %s: %w", anime.ID.Hex(), err)
+		}
+		for _, c := range changes {
+			fmt.Printf("%s %q: %s %v -> %v\n", anime.ID.Hex(), anime.Title, c.Field, c.Old, c.New)
+		}
+		return nil
+	})
+}
+
+func nextAiringEpisode(ep *logic.AiringEpisode) *dto.AiringEpisode {
+	if ep == nil {
+		return nil
+	}
+	return &dto.AiringEpisode{Episode: ep.Episode, AiringAt: ep.Time()}
+}
+
+func airingSchedule(nodes []logic.AiringEpisode) []dto.AiringEpisode {
+	schedule := make([]dto.AiringEpisode, 0, len(nodes))
+	for _, n := range nodes {
+		schedule = append(schedule, dto.AiringEpisode{Episode: n.Episode, AiringAt: n.Time()})
+	}
+	return schedule
+}
+
+func sameAiringEpisode(a, b *dto.AiringEpisode) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Episode == b.Episode && a.AiringAt.Equal(b.AiringAt)
+}
+
+// sameAiringSchedule compara as agendas episódio a episódio, incluindo o horário.
+func sameAiringSchedule(a, b []dto.AiringEpisode) bool {
+	return slices.EqualFunc(a, b, func(x, y dto.AiringEpisode) bool {
+		return sameAiringEpisode(&x, &y)
+	})
+}
@@ -0,0 +1,42 @@
+package scripts
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/gpt-utils/internal/dto"
+	"github.com/gpt-utils/internal/logic"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestRefreshAiringSavesScheduleOnlyChange(t *testing.T) {
+	next := logic.AiringEpisode{Episode: 5, AiringAt: time.Date(2026, 10, 20, 15, 0, 0, 0, time.UTC).Unix()}
+	// o episódio 6 foi adiado uma semana; status, episódios e o próximo episódio não mudam
+	delayed := logic.AiringEpisode{Episode: 6, AiringAt: time.Date(2026, 11, 3, 15, 0, 0, 0, time.UTC).Unix()}
+
+	animes := &fakeAnimes{}
+	id := animes.insert(t, dto.Anime{
+		AniListID:         testAniListID,
+		Title:             "Shingeki no Kyojin",
+		Status:            "RELEASING",
+		Episodes:          12,
+		NextAiringEpisode: &dto.AiringEpisode{Episode: 5, AiringAt: next.Time()},
+		AiringSchedule: []dto.AiringEpisode{
+			{Episode: 5, AiringAt: next.Time()},
+			{Episode: 6, AiringAt: time.Date(2026, 10, 27, 15, 0, 0, 0, time.UTC)},
+		},
+	})
+
+	info := &logic.AiringInfo{ID: testAniListID, Status: "RELEASING", Episodes: 12, NextAiringEpisode: &next}
+	info.AiringSchedule.Nodes = []logic.AiringEpisode{next, delayed}
+	r := &Runner{Animes: animes, AniList: &fakeAniList{airing: map[int]*logic.AiringInfo{testAniListID: info}}}
+
+	if err := RefreshAiring(context.Background(), r, Options{Script: "refresh-airing", Filter: bson.M{}}); err != nil {
+		t.Fatal(err)
+	}
+	got := animes.anime(t, id).AiringSchedule
+	if len(got) != 2 || !got[1].AiringAt.Equal(delayed.Time()) {
+		t.Errorf("airingSchedule = %+v, quer o episódio 6 em %v", got, delayed.Time())
+	}
+}
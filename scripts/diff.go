@@ -0,0 +1,267 @@
+package scripts
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gpt-utils/internal/dto"
+	"github.com/gpt-utils/internal/logic/utils"
+)
+
+const defaultReportDir = "reports"
+
+// FieldChange é um campo cujo valor atual difere do proposto pela AniList.
+type FieldChange struct {
+	Field string      `json:"field"`
+	Old   interface{} `json:"old"`
+	New   interface{} `json:"new"`
+}
+
+// CharacterChange descreve um personagem que seria adicionado ou alterado.
+type CharacterChange struct {
+	Name    string        `json:"name"`
+	Added   bool          `json:"added"`
+	Matched string        `json:"matched,omitempty"`
+	Fields  []FieldChange `json:"fields,omitempty"`
+}
+
+// AnimeDiff é o resultado do dry-run para um documento.
+type AnimeDiff struct {
+	ID         string            `json:"id"`
+	Title      string            `json:"title"`
+	NotFound   bool              `json:"notFound,omitempty"`
+	Match      *dto.AniListMatch `json:"match,omitempty"`
+	Fields     []FieldChange     `json:"fields,omitempty"`
+	Characters []CharacterChange `json:"characters,omitempty"`
+}
+
+func (d AnimeDiff) empty() bool {
+	return !d.NotFound && len(d.Fields) == 0 && len(d.Characters) == 0
+}
+
+func diffAnime(plan animePlan) AnimeDiff {
+	anime := plan.anime
+	media := plan.media.Data.Media
+
+	d := AnimeDiff{ID: anime.ID.Hex(), Title: anime.Title, Match: plan.match}
+	d.Fields = diffFields(
+		FieldChange{"aniListId", anime.AniListID, media.ID},
+		FieldChange{"malId", anime.MalID, media.IDMal},
+		FieldChange{"title", anime.Title, stringOr(plan.set["title"], anime.Title)},
+		FieldChange{"synonyms", anime.Synonyms, plan.set["synonyms"]},
+		FieldChange{"pathImage", anime.PathImage, stringOr(plan.set["pathImage"], anime.PathImage)},
+		FieldChange{"bannerPathImage", anime.BannerPathImage, stringOr(plan.set["bannerPathImage"], anime.BannerPathImage)},
+		FieldChange{"coverColor", anime.CoverColor, stringOr(plan.set["coverColor"], anime.CoverColor)},
+		FieldChange{"synopsis", anime.Synopsis, plan.set["synopsis"]},
+		FieldChange{"status", anime.Status, media.Status},
+		FieldChange{"episodes", anime.Episodes, media.Episodes},
+		FieldChange{"averageScore", anime.AverageScore, media.AverageScore},
+		FieldChange{"type", anime.Type, media.Type},
+		FieldChange{"format", anime.Format, media.Format},
+		FieldChange{"countryOfOrigin", anime.CountryOfOrigin, media.CountryOfOrigin},
+		FieldChange{"source", anime.Source, media.Source},
+		FieldChange{"duration", anime.Duration, media.Duration},
+		FieldChange{"isAdult", anime.IsAdult, media.IsAdult},
+		FieldChange{"startDate", anime.StartDate, dto.StartDate(media.StartDate)},
+		FieldChange{"endDate", anime.EndDate, dto.EndDate(media.EndDate)},
+		FieldChange{"studios", studioNames(anime.Studios), studioNames(plan.studios)},
+		FieldChange{"mainStudio", studioNames(mainStudioList(anime.MainStudio)), studioNames(mainStudioList(mainStudio(plan.studios)))},
+		FieldChange{"staffs", staffNames(anime.Staffs), staffNames(plan.staffs)},
+		FieldChange{"externalLinks", anime.ExternalLinks, plan.set["externalLinks"]},
+		FieldChange{"trailer", anime.Trailer, plan.set["trailer"]},
+		FieldChange{"relations", relationNames(anime.Relations), relationNames(plan.relations)},
+		FieldChange{"genres", anime.Genres, media.Genres},
+		FieldChange{"tags", anime.Tags, plan.set["tags"]},
+	)
+
+	for _, cp := range plan.characters {
+		if cp.matched == nil {
+			d.Characters = append(d.Characters, CharacterChange{Name: cp.added.Name, Added: true})
+			continue
+		}
+
+		fields := diffFields(
+			FieldChange{"bio", cp.matched.Bio, cp.set["characters.$[c].bio"]},
+			FieldChange{"link", cp.matched.Link, cp.edge.Node.SiteURL},
+			FieldChange{"age", cp.matched.Age, cp.edge.Node.Age},
+			FieldChange{"dateOfBirth", cp.matched.DateOfBirth, cp.edge.Node.DateOfBirth},
+			FieldChange{"voiceActors", voiceActorNames(cp.matched.VoiceActors), voiceActorNames(cp.voiceActors)},
+		)
+		if len(fields) > 0 {
+			d.Characters = append(d.Characters, CharacterChange{
+				Name:    cp.edge.Node.Name.Full,
+				Matched: cp.matched.Name,
+				Fields:  fields,
+			})
+		}
+	}
+
+	return d
+}
+
+// diffFields devolve só as mudanças em que Old e New diferem; slices vazios e nil são iguais.
+func diffFields(changes ...FieldChange) []FieldChange {
+	var out []FieldChange
+	for _, c := range changes {
+		if isEmptySlice(c.Old) && isEmptySlice(c.New) {
+			continue
+		}
+		if !reflect.DeepEqual(c.Old, c.New) {
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+func isEmptySlice(v interface{}) bool {
+	rv := reflect.ValueOf(v)
+	return rv.Kind() == reflect.Slice && rv.Len() == 0
+}
+
+// stringOr devolve v se for string, senão fallback (campo fora do $set).
+func stringOr(v interface{}, fallback string) string {
+	if s, ok := v.(string); ok {
+		return s
+	}
+	return fallback
+}
+
+func studioNames(studios []dto.Studio) []string {
+	var names []string
+	for _, s := range studios {
+		names = append(names, s.Name)
+	}
+	return names
+}
+
+func staffNames(staffs []dto.Staff) []string {
+	var names []string
+	for _, s := range staffs {
+		if len(s.Roles) > 0 {
+			names = append(names, s.Name+" ("+strings.Join(s.Roles, ", ")+")")
+			continue
+		}
+		names = append(names, s.Name)
+	}
+	return names
+}
+
+func relationNames(relations []dto.Relation) []string {
+	var names []string
+	for _, rel := range relations {
+		names = append(names, rel.Type+": "+rel.Title)
+	}
+	return names
+}
+
+func voiceActorNames(vas []dto.VoiceActor) []string {
+	var names []string
+	for _, va := range vas {
+		names = append(names, va.Name)
+	}
+	return names
+}
+
+// writeDiffReport grava os diffs do dry-run em JSON e Markdown dentro de dir.
+func writeDiffReport(dir, script string, diffs []AnimeDiff) error {
+	if dir == "" {
+		dir = defaultReportDir
+	}
+
+	// com --workers os diffs chegam fora de ordem
+	sort.Slice(diffs, func(i, j int) bool { return diffs[i].ID < diffs[j].ID })
+
+	changed := make([]AnimeDiff, 0, len(diffs))
+	for _, d := range diffs {
+		if !d.empty() {
+			changed = append(changed, d)
+		}
+	}
+
+	data, err := json.Marshal(changed)
+	if err != nil {
+		return fmt.Errorf("erro ao codificar diff: %w", err)
+	}
+	jsonPath, err := utils.SaveJSONToFile(data, script+"-dry-run", dir)
+	if err != nil {
+		return err
+	}
+
+	mdPath := strings.TrimSuffix(jsonPath, filepath.Ext(jsonPath)) + ".md"
+	if err := os.WriteFile(mdPath, renderDiffMarkdown(script, len(diffs), changed), 0644); err != nil {
+		return fmt.Errorf("erro ao salvar arquivo: %w", err)
+	}
+
+	fmt.Printf("dry-run: %d de %d animes com alterações\n  %s\n  %s\n", len(changed), len(diffs), jsonPath, mdPath)
+	return nil
+}
+
+func renderDiffMarkdown(script string, total int, diffs []AnimeDiff) []byte {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "# Dry-run %s — %s\n\n", script, time.Now().Format(time.RFC3339))
+	fmt.Fprintf(&b, "%d de %d animes com alterações.\n", len(diffs), total)
+
+	for _, d := range diffs {
+		fmt.Fprintf(&b, "\n## %s (`%s`)\n\n", d.Title, d.ID)
+		if d.Match != nil {
+			status := "aceito"
+			if !d.Match.Accepted {
+				status = "abaixo do limite, não aplicado"
+			}
+			fmt.Fprintf(&b, "Match AniList: %s (id %d), nota %.2f — %s.\n\n", d.Match.Title, d.Match.AniListID, d.Match.Score, status)
+		}
+		if d.NotFound {
+			b.WriteString("Não encontrado na AniList.\n")
+			continue
+		}
+
+		if len(d.Fields) > 0 {
+			b.WriteString("| campo | atual | AniList |\n|---|---|---|\n")
+			for _, f := range d.Fields {
+				fmt.Fprintf(&b, "| %s | %s | %s |\n", f.Field, markdownCell(f.Old), markdownCell(f.New))
+			}
+		}
+
+		var added []string
+		for _, c := range d.Characters {
+			if c.Added {
+				added = append(added, c.Name)
+			}
+		}
+		if len(added) > 0 {
+			fmt.Fprintf(&b, "\n**Personagens adicionados (%d):** %s\n", len(added), strings.Join(added, ", "))
+		}
+
+		for _, c := range d.Characters {
+			if c.Added {
+				continue
+			}
+			fields := make([]string, 0, len(c.Fields))
+			for _, f := range c.Fields {
+				fields = append(fields, f.Field)
+			}
+			fmt.Fprintf(&b, "\n- **%s** (atual: %s): %s", c.Name, c.Matched, strings.Join(fields, ", "))
+		}
+		b.WriteString("\n")
+	}
+	return b.Bytes()
+}
+
+func markdownCell(v interface{}) string {
+	s := fmt.Sprint(v)
+	if b, err := json.Marshal(v); err == nil && !strings.HasPrefix(string(b), "\"") {
+		s = string(b)
+	}
+	s = strings.NewReplacer("\n", " ", "\r", "", "|", "\\|").Replace(s)
+	if r := []rune(s); len(r) > 120 {
+		s = string(r[:117]) + "..."
+	}
+	return s
+}
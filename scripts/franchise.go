@@ -0,0 +1,258 @@
+package scripts
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+
+	"github.com/gpt-utils/internal/dto"
+	"github.com/gpt-utils/internal/logic/utils"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// franchiseRelations são os tipos de relação que colocam dois animes na
+// mesma franquia. ADAPTATION/SOURCE (mangá) e CHARACTER (crossover) ficam de fora.
+var franchiseRelations = map[string]bool{
+	"SEQUEL":      true,
+	"PREQUEL":     true,
+	"PARENT":      true,
+	"SIDE_STORY":  true,
+	"SPIN_OFF":    true,
+	"ALTERNATIVE": true,
+	"SUMMARY":     true,
+	"COMPILATION": true,
+	"CONTAINS":    true,
+}
+
+type franchiseGroup struct {
+	ID      int              `json:"id"`
+	Entries []franchiseEntry `json:"entries"`
+}
+
+type franchiseEntry struct {
+	Order     int           `json:"order"`
+	AniListID int           `json:"aniListId"`
+	AnimeID   string        `json:"animeId"`
+	Title     string        `json:"title"`
+	Format    string        `json:"format"`
+	StartDate dto.StartDate `json:"startDate"`
+}
+
+// Franchise agrupa os animes do catálogo em franquias a partir das relações
+// da AniList, calcula a ordem de exibição de cada grupo (PREQUEL/SEQUEL
+// primeiro, data de estreia como desempate) e grava o resultado em
+// franchise, ligando também relations.animeId aos nossos documentos.
+func Franchise(ctx context.Context, r *Runner, opts Options) error {
+	animes, err := r.Animes.ListAnimeRelations(ctx, opts.Filter)
+	if err != nil {
+		return fmt.Errorf("falha ao listar animes: %w", err)
+	}
+
+	byAniList := make(map[int]*dto.Anime, len(animes))
+	for i := range animes {
+		byAniList[animes[i].AniListID] = &animes[i]
+	}
+
+	groups := groupFranchises(animes, byAniList)
+
+	report := make([]franchiseGroup, 0, len(groups))
+	for _, group := range groups {
+		ordered := watchOrder(group, byAniList)
+		fg := franchiseGroup{ID: ordered[0].AniListID}
+		for i, anime := range ordered {
+			fg.Entries = append(fg.Entries, franchiseEntry{
+				Order:     i + 1,
+				AniListID: anime.AniListID,
+				AnimeID:   anime.ID.Hex(),
+				Title:     anime.Title,
+				Format:    anime.Format,
+				StartDate: anime.StartDate,
+			})
+			if err := r.saveFranchise(ctx, opts, anime, byAniList, dto.FranchiseInfo{ID: fg.ID, Order: i + 1, Size: len(ordered)}); err != nil {
+				return err
+			}
+		}
+		report = append(report, fg)
+	}
+
+	sort.Slice(report, func(i, j int) bool { return len(report[i].Entries) > len(report[j].Entries) })
+
+	var multi int
+	for _, fg := range report {
+		if len(fg.Entries) > 1 {
+			multi++
+		}
+	}
+	fmt.Printf("%d animes em %d franquias (%d com mais de um título)\n", len(animes), len(report), multi)
+
+	data, err := json.Marshal(report)
+	if err != nil {
+		return fmt.Errorf("erro ao codificar franquias: %w", err)
+	}
+	dir := opts.ReportDir
+	if dir == "" {
+		dir = defaultReportDir
+	}
+	path, err := utils.SaveJSONToFile(data, "franchise", dir)
+	if err != nil {
+		return err
+	}
+	fmt.Println(path)
+	return nil
+}
+
+// groupFranchises junta (union-find) os animes ligados por franchiseRelations.
+func groupFranchises(animes []dto.Anime, byAniList map[int]*dto.Anime) [][]*dto.Anime {
+	parent := make(map[int]int, len(animes))
+	var find func(int) int
+	find = func(x int) int {
+		if parent[x] != x {
+			parent[x] = find(parent[x])
+		}
+		return parent[x]
+	}
+	for _, a := range animes {
+		parent[a.AniListID] = a.AniListID
+	}
+
+	for _, a := range animes {
+		for _, rel := range a.Relations {
+			if !franchiseRelations[rel.Type] || byAniList[rel.AniListID] == nil {
+				continue
+			}
+			ra, rb := find(a.AniListID), find(rel.AniListID)
+			if ra != rb {
+				parent[ra] = rb
+			}
+		}
+	}
+
+	members := make(map[int][]*dto.Anime)
+	var roots []int
+	for i := range animes {
+		root := find(animes[i].AniListID)
+		if _, ok := members[root]; !ok {
+			roots = append(roots, root)
+		}
+		members[root] = append(members[root], &animes[i])
+	}
+
+	groups := make([][]*dto.Anime, 0, len(roots))
+	for _, root := range roots {
+		groups = append(groups, members[root])
+	}
+	return groups
+}
+
+// watchOrder ordena o grupo topologicamente pelas relações PREQUEL/SEQUEL,
+// escolhendo sempre o título disponível de estreia mais antiga. Títulos
+// presos em ciclos vão para o fim, por data.
+func watchOrder(group []*dto.Anime, byAniList map[int]*dto.Anime) []*dto.Anime {
+	inGroup := make(map[int]bool, len(group))
+	for _, a := range group {
+		inGroup[a.AniListID] = true
+	}
+
+	type edge struct{ from, to int }
+	edges := make(map[edge]bool)
+	for _, a := range group {
+		for _, rel := range a.Relations {
+			if !inGroup[rel.AniListID] {
+				continue
+			}
+			switch rel.Type {
+			case "SEQUEL":
+				edges[edge{a.AniListID, rel.AniListID}] = true
+			case "PREQUEL":
+				edges[edge{rel.AniListID, a.AniListID}] = true
+			}
+		}
+	}
+
+	indegree := make(map[int]int, len(group))
+	next := make(map[int][]int)
+	for e := range edges {
+		indegree[e.to]++
+		next[e.from] = append(next[e.from], e.to)
+	}
+
+	less := func(a, b *dto.Anime) bool {
+		da, db := dateKey(a.StartDate), dateKey(b.StartDate)
+		if da != db {
+			return da < db
+		}
+		return a.AniListID < b.AniListID
+	}
+
+	var ready []*dto.Anime
+	for _, a := range group {
+		if indegree[a.AniListID] == 0 {
+			ready = append(ready, a)
+		}
+	}
+
+	ordered := make([]*dto.Anime, 0, len(group))
+	placed := make(map[int]bool, len(group))
+	for len(ready) > 0 {
+		sort.Slice(ready, func(i, j int) bool { return less(ready[i], ready[j]) })
+		a := ready[0]
+		ready = ready[1:]
+		ordered = append(ordered, a)
+		placed[a.AniListID] = true
+		for _, id := range next[a.AniListID] {
+			indegree[id]--
+			if indegree[id] == 0 {
+				ready = append(ready, byAniList[id])
+			}
+		}
+	}
+
+	var rest []*dto.Anime
+	for _, a := range group {
+		if !placed[a.AniListID] {
+			rest = append(rest, a)
+		}
+	}
+	sort.Slice(rest, func(i, j int) bool { return less(rest[i], rest[j]) })
+	return append(ordered, rest...)
+}
+
+// dateKey transforma a data em um número ordenável; datas desconhecidas vão para o fim.
+func dateKey(d dto.StartDate) int {
+	if d.Year == 0 {
+		return 1 << 30
+	}
+	return d.Year*10000 + d.Month*100 + d.Day
+}
+
+// saveFranchise grava a posição do anime na franquia e liga as relações com
+// animes do catálogo. Não escreve nada se o documento já estiver atualizado.
+func (r *Runner) saveFranchise(ctx context.Context, opts Options, anime *dto.Anime, byAniList map[int]*dto.Anime, info dto.FranchiseInfo) error {
+	relations := make([]dto.Relation, len(anime.Relations))
+	copy(relations, anime.Relations)
+	linked := false
+	for i := range relations {
+		other := byAniList[relations[i].AniListID]
+		if other == nil || relations[i].MediaType != "ANIME" || relations[i].AnimeID != nil {
+			continue
+		}
+		id := other.ID
+		relations[i].AnimeID = &id
+		linked = true
+	}
+
+	if !linked && anime.Franchise != nil && reflect.DeepEqual(*anime.Franchise, info) {
+		return nil
+	}
+
+	set := bson.M{"franchise": info}
+	if linked {
+		set["relations"] = relations
+	}
+	if _, err := r.updateOne(ctx, opts, bson.M{"_id": anime.ID}, bson.M{"$set": set}); err != nil {
+		return fmt.Errorf("erro ao salvar franquia de %s: %w", anime.ID.Hex(), err)
+	}
+	return nil
+}
@@ -5,45 +5,24 @@ import (
 	"encoding/json"
 	"fmt"
 	"log"
-	"os"
 	"strings"
 
-	"github.com/gpt-utils/internal/logic"
+	"github.com/gpt-utils/internal/dto"
 	"github.com/gpt-utils/internal/logic/utils"
-	"github.com/joho/godotenv"
 	"go.mongodb.org/mongo-driver/bson"
 )
 
-func UpdateAnime() {
-	ctx := context.Background()
-	defer ctx.Done()
-
-	if err := godotenv.Load(); err != nil {
-		log.Fatal("Erro ao carregar arquivo .env:", err)
-	}
-
-	logic.Connect("mongodb://localhost:27017/animeSearch")
-
-	apiKey := os.Getenv("OPENAI_API_KEY")
-
-	client := logic.GetDB() // deve retornar *mongo.Client
-	collection := client.Database("animeSearch").Collection("animes")
-
-	if apiKey == "" {
-		log.Fatal("OPENAI_API_KEY não definido no ambiente")
-		ctx.Done()
+func UpdateAnime(ctx context.Context, r *Runner, opts Options) error {
+	if r.OpenAI == nil {
+		return fmt.Errorf("OPENAI_API_KEY não definido no ambiente")
 	}
 
-	rep := logic.NewQueryAnimeMongo(collection)
-
-	animes, err := rep.ListPageAnime(ctx, 1, 1, bson.M{})
-	if err != nil {
-		log.Fatal("ListPageAnime error")
-		ctx.Done()
-	}
-
-	anime := animes[0]
+	return r.eachAnime(ctx, opts, func(anime dto.Anime) error {
+		return r.updateAnimeWithGPT(ctx, opts, anime)
+	})
+}
 
+func (r *Runner) updateAnimeWithGPT(ctx context.Context, opts Options, anime dto.Anime) error {
 	var charactersStr string
 	for _, character := range anime.Characters {
 		charactersStr += fmt.Sprintf(
@@ -84,9 +63,9 @@ func UpdateAnime() {
 		charactersStr, // pode estar vazio, tudo bem
 	)
 
-	response, err := logic.CallOpenAI(apiKey, model, input)
+	response, err := r.OpenAI.CallOpenAI(model, input)
 	if err != nil {
-		log.Fatal("Erro ao chamar OpenAI:", err)
+		return fmt.Errorf("erro ao chamar OpenAI: %w", err)
 	}
 	// Parsear a resposta JSON completa da API
 	var apiResp struct {
@@ -98,11 +77,11 @@ func UpdateAnime() {
 	}
 
 	if err := json.Unmarshal([]byte(response), &apiResp); err != nil {
-		log.Fatalf("Erro ao parsear resposta completa da API: %v", err)
+		return fmt.Errorf("erro ao parsear resposta completa da API: %w", err)
 	}
 
 	if len(apiResp.Output) == 0 || len(apiResp.Output[0].Content) == 0 {
-		log.Fatal("Resposta do GPT não contém output esperado")
+		return fmt.Errorf("resposta do GPT não contém output esperado")
 	}
 
 	gptJsonStr := apiResp.Output[0].Content[0].Text
@@ -114,16 +93,16 @@ func UpdateAnime() {
 	// Agora tenta parsear
 	var updatedData map[string]interface{}
 	if err := json.Unmarshal([]byte(gptJsonStr), &updatedData); err != nil {
-		log.Fatalf("Erro ao parsear JSON do GPT: %v", err)
+		return fmt.Errorf("erro ao parsear JSON do GPT: %w", err)
 	}
 
 	// Fazer update no Mongo
-	filter := bson.M{"title": anime.Title}
+	filter := bson.M{"_id": anime.ID}
 	update := bson.M{"$set": updatedData}
 
-	count, err := rep.UpdateOne(ctx, filter, update)
+	count, err := r.updateOne(ctx, opts, filter, update)
 	if err != nil {
-		log.Fatalf("Erro ao atualizar documento: %v", err)
+		return fmt.Errorf("erro ao atualizar documento: %w", err)
 	}
 
 	fmt.Printf("Documentos atualizados: %d\n", count)
@@ -132,8 +111,9 @@ func UpdateAnime() {
 	outputDir := "results"
 	filePath, err := utils.SaveJSONToFile(response, filenamePrefix, outputDir)
 	if err != nil {
-		log.Fatal("Erro ao salvar arquivo:", err)
+		return fmt.Errorf("erro ao salvar arquivo: %w", err)
 	}
 
 	log.Printf("Resposta salva em: %s\n", filePath)
+	return nil
 }
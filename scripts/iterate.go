@@ -0,0 +1,319 @@
+package scripts
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/gpt-utils/internal/dto"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const defaultPageSize = 100
+
+// errAnimeNotFound é devolvido pelos scripts quando o anime não existe na
+// fonte consultada; eachAnime registra o documento como não encontrado.
+var errAnimeNotFound = errors.New("anime não encontrado")
+
+// eachAnime percorre os animes que casam com opts.Filter em páginas de
+// opts.PageSize, ordenados por _id, respeitando opts.Limit.
+//
+// Fora do dry-run cada execução é registrada em job_runs: o resultado de
+// cada documento e o último _id processado são salvos, e uma falha em um
+// documento é registrada sem interromper os demais. Com opts.Resume a
+// execução continua a partir do checkpoint salvo.
+func (r *Runner) eachAnime(ctx context.Context, opts Options, fn func(anime dto.Anime) error) error {
+	return eachDocument(ctx, r, opts, r.Animes.ListAnimesAfter, func(a dto.Anime) (primitive.ObjectID, string) {
+		return a.ID, a.Title
+	}, fn)
+}
+
+// eachManga é o eachAnime da coleção mangas.
+func (r *Runner) eachManga(ctx context.Context, opts Options, fn func(manga dto.Manga) error) error {
+	return eachDocument(ctx, r, opts, r.Mangas.ListMangasAfter, func(m dto.Manga) (primitive.ObjectID, string) {
+		return m.ID, m.Title
+	}, fn)
+}
+
+// listAfter lista até pageSize documentos depois de after, ordenados por _id.
+type listAfter[T any] func(ctx context.Context, after primitive.ObjectID, pageSize int, query bson.M) ([]T, error)
+
+// docKey devolve o _id e o título do documento, usados no checkpoint e nos logs.
+type docKey[T any] func(doc T) (primitive.ObjectID, string)
+
+func eachDocument[T any](ctx context.Context, r *Runner, opts Options, list listAfter[T], key docKey[T], fn func(doc T) error) error {
+	pageSize := opts.PageSize
+	if pageSize <= 0 {
+		pageSize = defaultPageSize
+	}
+
+	run, err := r.startJobRun(ctx, &opts)
+	if err != nil {
+		return err
+	}
+
+	var (
+		last      primitive.ObjectID
+		processed int
+	)
+	if run != nil {
+		last = run.LastID
+	}
+
+	err = func() error {
+		for {
+			size := pageSize
+			if opts.Limit > 0 && opts.Limit-processed < size {
+				size = opts.Limit - processed
+			}
+			if size <= 0 {
+				return nil
+			}
+
+			listCtx, cancel := context.WithTimeout(ctx, 3*time.Minute)
+			docs, err := list(listCtx, last, size, opts.Filter)
+			cancel()
+			if err != nil {
+				return fmt.Errorf("falha ao listar documentos: %w", err)
+			}
+
+			n, err := processPage(ctx, r, run, opts.Workers, docs, key, fn)
+			processed += n
+			if n > 0 {
+				last, _ = key(docs[n-1])
+			}
+			if err != nil {
+				return err
+			}
+
+			if len(docs) < size {
+				if run != nil {
+					run.Status = dto.JobRunFinished
+				}
+				return nil
+			}
+		}
+	}()
+
+	return r.finishJobRun(run, err)
+}
+
+// processPage executa fn nos documentos da página com até workers goroutines.
+// Os resultados são registrados na ordem dos _id, de modo que o checkpoint
+// só avança sobre um prefixo contínuo de documentos concluídos. Devolve
+// quantos documentos desse prefixo foram registrados.
+func processPage[T any](ctx context.Context, r *Runner, run *dto.JobRun, workers int, docs []T, key docKey[T], fn func(doc T) error) (int, error) {
+	if workers <= 0 {
+		workers = 1
+	}
+
+	var (
+		mu        sync.Mutex
+		wg        sync.WaitGroup
+		results   = make([]error, len(docs))
+		done      = make([]bool, len(docs))
+		next      int
+		recordErr error
+		sem       = make(chan struct{}, workers)
+	)
+
+	// o checkpoint precisa ser salvo mesmo se a execução for interrompida
+	recordCtx := context.WithoutCancel(ctx)
+
+dispatch:
+	for i, doc := range docs {
+		select {
+		case <-ctx.Done():
+			break dispatch
+		case sem <- struct{}{}:
+		}
+
+		mu.Lock()
+		failed := recordErr != nil
+		mu.Unlock()
+		if failed {
+			<-sem
+			break
+		}
+
+		wg.Add(1)
+		go func(i int, doc T) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			err := fn(doc)
+			if err != nil && ctx.Err() != nil {
+				// interrompido no meio do documento: não avança o checkpoint
+				return
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			results[i], done[i] = err, true
+			for next < len(docs) && done[next] && recordErr == nil {
+				id, title := key(docs[next])
+				recordErr = r.recordOutcome(recordCtx, run, id, title, results[next])
+				if recordErr == nil {
+					next++
+				}
+			}
+		}(i, doc)
+	}
+	wg.Wait()
+
+	if recordErr != nil {
+		return next, recordErr
+	}
+	if next < len(docs) {
+		return next, ctx.Err()
+	}
+	return next, nil
+}
+
+// startJobRun cria (ou, com opts.Resume, carrega) o registro em job_runs.
+// Devolve nil quando a execução não é rastreada (dry-run ou sem repositório).
+func (r *Runner) startJobRun(ctx context.Context, opts *Options) (*dto.JobRun, error) {
+	if opts.DryRun || r.Jobs == nil {
+		if opts.Resume != "" {
+			return nil, fmt.Errorf("--resume não pode ser usado com --dry-run")
+		}
+		return nil, nil
+	}
+
+	if opts.Resume != "" {
+		run, err := r.Jobs.FindJobRun(ctx, opts.Resume)
+		if err != nil {
+			return nil, fmt.Errorf("execução %s não encontrada: %w", opts.Resume, err)
+		}
+		if run.Script != opts.Script {
+			return nil, fmt.Errorf("execução %s pertence ao script %s", run.ID, run.Script)
+		}
+		if run.Status == dto.JobRunFinished {
+			return nil, fmt.Errorf("execução %s já terminou", run.ID)
+		}
+
+		var filter bson.M
+		if err := bson.UnmarshalExtJSON([]byte(run.Filter), false, &filter); err != nil {
+			return nil, fmt.Errorf("filtro salvo na execução %s é inválido: %w", run.ID, err)
+		}
+		opts.Filter = filter
+
+		if err := r.Jobs.SetJobRunStatus(ctx, run.ID, dto.JobRunRunning, ""); err != nil {
+			return nil, err
+		}
+		run.Status = dto.JobRunRunning
+		fmt.Printf("retomando execução %s a partir de %s\n", run.ID, run.LastID.Hex())
+		return run, nil
+	}
+
+	filter, err := bson.MarshalExtJSON(opts.Filter, false, false)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao serializar filtro: %w", err)
+	}
+
+	now := time.Now()
+	run := &dto.JobRun{
+		ID:        primitive.NewObjectID().Hex(),
+		Script:    opts.Script,
+		Filter:    string(filter),
+		Status:    dto.JobRunRunning,
+		Outcomes:  []dto.JobOutcome{},
+		StartedAt: now,
+		UpdatedAt: now,
+	}
+	if err := r.Jobs.CreateJobRun(ctx, run); err != nil {
+		return nil, fmt.Errorf("erro ao registrar execução: %w", err)
+	}
+	fmt.Printf("execução %s iniciada (use --resume %s para continuar)\n", run.ID, run.ID)
+	return run, nil
+}
+
+// recordOutcome registra o resultado de um documento. Só devolve erro
+// quando o próprio checkpoint não pôde ser salvo.
+func (r *Runner) recordOutcome(ctx context.Context, run *dto.JobRun, id primitive.ObjectID, title string, fnErr error) error {
+	outcome := dto.JobOutcome{
+		AnimeID: id,
+		Title:   title,
+		Outcome: dto.OutcomeSuccess,
+		At:      time.Now(),
+	}
+	switch {
+	case errors.Is(fnErr, errAnimeNotFound):
+		outcome.Outcome = dto.OutcomeNotFound
+	case fnErr != nil:
+		outcome.Outcome = dto.OutcomeFailure
+		outcome.Error = fnErr.Error()
+		log.Printf("falha em %s (%s): %v", id.Hex(), title, fnErr)
+	}
+
+	if run == nil {
+		return nil
+	}
+	if err := r.Jobs.RecordJobOutcome(ctx, run.ID, id, outcome); err != nil {
+		return fmt.Errorf("erro ao salvar checkpoint da execução %s: %w", run.ID, err)
+	}
+	return nil
+}
+
+func (r *Runner) finishJobRun(run *dto.JobRun, err error) error {
+	if run == nil {
+		return err
+	}
+
+	status, msg := run.Status, ""
+	switch {
+	case err != nil && errors.Is(err, context.Canceled):
+		status, msg = dto.JobRunStopped, err.Error()
+	case err != nil:
+		status, msg = dto.JobRunFailed, err.Error()
+	case status != dto.JobRunFinished:
+		// parou pelo --limit
+		status = dto.JobRunStopped
+	}
+
+	// usa um contexto novo: o da execução pode já ter sido cancelado
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if serr := r.Jobs.SetJobRunStatus(ctx, run.ID, status, msg); serr != nil && err == nil {
+		err = serr
+	}
+	fmt.Printf("execução %s: %s\n", run.ID, status)
+	return err
+}
+
+// markNotFound grava set no anime e devolve errAnimeNotFound para que a
+// execução registre o documento como não encontrado.
+func (r *Runner) markNotFound(ctx context.Context, opts Options, anime dto.Anime, set bson.M) error {
+	log.Printf("%s (%s) não encontrado", anime.ID.Hex(), anime.Title)
+	if _, err := r.updateOne(ctx, opts, bson.M{"_id": anime.ID}, bson.M{"$set": set}); err != nil {
+		return fmt.Errorf("erro ao marcar %s como não encontrado: %w", anime.ID.Hex(), err)
+	}
+	return errAnimeNotFound
+}
+
+// updateOne aplica o update, ou apenas o registra no log quando opts.DryRun está ativo.
+func (r *Runner) updateOne(ctx context.Context, opts Options, filter, update interface{}, updateOpts ...*options.UpdateOptions) (int64, error) {
+	return r.updateIn(ctx, opts, r.Animes, filter, update, updateOpts...)
+}
+
+// updateManga é o updateOne da coleção mangas.
+func (r *Runner) updateManga(ctx context.Context, opts Options, filter, update interface{}, updateOpts ...*options.UpdateOptions) (int64, error) {
+	return r.updateIn(ctx, opts, r.Mangas, filter, update, updateOpts...)
+}
+
+type updater interface {
+	UpdateOne(ctx context.Context, filter interface{}, update interface{}, opts ...*options.UpdateOptions) (int64, error)
+}
+
+func (r *Runner) updateIn(ctx context.Context, opts Options, repo updater, filter, update interface{}, updateOpts ...*options.UpdateOptions) (int64, error) {
+	if opts.DryRun {
+		log.Printf("[dry-run] update %v: %v", filter, update)
+		return 0, nil
+	}
+	return repo.UpdateOne(ctx, filter, update, updateOpts...)
+}
@@ -0,0 +1,128 @@
+package scripts
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/gpt-utils/internal/dto"
+	"github.com/gpt-utils/internal/logic"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+const (
+	defaultMatchThreshold = 0.75
+	matchCandidates       = 10
+	// reviewCandidates é quantos candidatos ficam salvos em cada revisão.
+	reviewCandidates = 5
+)
+
+// resolveAniListID devolve o ID AniList salvo no anime ou, se ainda não
+// houver, o do candidato aceito por matchAnime (que também é devolvido para
+// ser gravado junto com os dados do anime).
+func (r *Runner) resolveAniListID(ctx context.Context, opts Options, anime dto.Anime) (int, *dto.AniListMatch, error) {
+	if anime.AniListID != 0 {
+		return anime.AniListID, nil, nil
+	}
+	match, _, err := r.matchAnime(ctx, opts, anime)
+	if err != nil {
+		return 0, match, err
+	}
+	return match.AniListID, match, nil
+}
+
+// matchAnime descobre o ID AniList de um anime ainda não casado: busca uma
+// página de candidatos pelo título (e pelas outras variantes e sinônimos,
+// se o título não trouxer nada) e aceita o de maior nota se ela atingir opts.MatchThreshold.
+//
+// Abaixo do limite o candidato é gravado em aniListMatch com Accepted false
+// e a função devolve errAnimeNotFound; o anime vai para match_reviews,
+// assim como quando nenhum candidato é encontrado.
+func (r *Runner) matchAnime(ctx context.Context, opts Options, anime dto.Anime) (*dto.AniListMatch, []logic.ScoredCandidate, error) {
+	var candidates []logic.MediaCandidate
+	for _, search := range anime.TitleVariants() {
+		found, err := r.AniList.SearchAnimeCandidates(ctx, search, matchCandidates)
+		if err != nil {
+			return nil, nil, fmt.Errorf("falha ao buscar candidatos de %q: %w", search, err)
+		}
+		if len(found) > 0 {
+			candidates = found
+			break
+		}
+	}
+
+	ranked := logic.RankCandidates(anime, candidates)
+	if len(ranked) == 0 {
+		if err := r.queueReview(ctx, opts, anime, dto.ReviewReasonNotFound, nil); err != nil {
+			return nil, nil, err
+		}
+		return nil, nil, errAnimeNotFound
+	}
+
+	best := ranked[0]
+	match := &dto.AniListMatch{
+		AniListID: best.Media.ID,
+		Title:     best.Media.Title.Romaji,
+		Score:     best.Score,
+		Accepted:  best.Score >= matchThreshold(opts),
+		MatchedAt: time.Now(),
+	}
+	if match.Accepted {
+		return match, ranked, nil
+	}
+
+	if _, err := r.updateOne(ctx, opts, bson.M{"_id": anime.ID}, bson.M{"$set": bson.M{"aniListMatch": match}}); err != nil {
+		return nil, nil, fmt.Errorf("erro ao salvar match de %s: %w", anime.ID.Hex(), err)
+	}
+	if err := r.queueReview(ctx, opts, anime, dto.ReviewReasonLowConfidence, ranked); err != nil {
+		return nil, nil, err
+	}
+	return match, ranked, fmt.Errorf("%w: melhor candidato %q com nota %.2f", errAnimeNotFound, match.Title, match.Score)
+}
+
+func matchThreshold(opts Options) float64 {
+	if opts.MatchThreshold <= 0 {
+		return defaultMatchThreshold
+	}
+	return opts.MatchThreshold
+}
+
+// queueReview coloca o anime em match_reviews com os melhores candidatos.
+func (r *Runner) queueReview(ctx context.Context, opts Options, anime dto.Anime, reason string, ranked []logic.ScoredCandidate) error {
+	if opts.DryRun || r.Reviews == nil {
+		return nil
+	}
+
+	review := dto.MatchReview{
+		AnimeID:    anime.ID,
+		Title:      anime.Title,
+		Synonyms:   anime.Synonyms,
+		Format:     anime.Format,
+		Episodes:   anime.Episodes,
+		StartYear:  anime.StartDate.Year,
+		Reason:     reason,
+		Candidates: []dto.ReviewCandidate{},
+	}
+	for i, c := range ranked {
+		if i == reviewCandidates {
+			break
+		}
+		review.Candidates = append(review.Candidates, dto.ReviewCandidate{
+			AniListID: c.Media.ID,
+			MalID:     c.Media.IDMal,
+			Romaji:    c.Media.Title.Romaji,
+			English:   c.Media.Title.English,
+			Native:    c.Media.Title.Native,
+			Synonyms:  c.Media.Synonyms,
+			Format:    c.Media.Format,
+			Episodes:  c.Media.Episodes,
+			StartYear: c.Media.StartDate.Year,
+			Score:     c.Score,
+		})
+	}
+
+	if err := r.Reviews.UpsertPendingReview(ctx, review); err != nil {
+		return fmt.Errorf("erro ao enfileirar revisão de %s: %w", anime.ID.Hex(), err)
+	}
+	return nil
+}
@@ -0,0 +1,31 @@
+package scripts
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/gpt-utils/internal/logic"
+)
+
+// MigrateBSONKeys renomeia nos animes as chaves gravadas antes de dto.Anime e
+// dto.Character terem tags bson (ex.: anilistapi e voiceactors) para as
+// chaves atuais (aniListApi, voiceActors). Sem isso esses campos voltam
+// zerados na leitura. Com --dry-run só conta os animes afetados.
+func MigrateBSONKeys(ctx context.Context, r *Runner, opts Options) error {
+	pending, err := r.Animes.Count(ctx, logic.LegacyAnimeKeysFilter())
+	if err != nil {
+		return fmt.Errorf("falha ao contar animes com chaves antigas: %w", err)
+	}
+	if opts.DryRun || pending == 0 {
+		fmt.Printf("%d animes com chaves antigas\n", pending)
+		return nil
+	}
+
+	updated, err := r.Animes.MigrateLegacyAnimeKeys(ctx)
+	if err != nil {
+		return fmt.Errorf("falha ao migrar chaves antigas: %w", err)
+	}
+	log.Printf("%d animes com chaves antigas, %d atualizações feitas", pending, updated)
+	return nil
+}
@@ -0,0 +1,126 @@
+package scripts
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"text/tabwriter"
+
+	"github.com/gpt-utils/internal/dto"
+	"github.com/gpt-utils/internal/logic"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// linkPeople grava em people a staff e os dubladores do plano e preenche
+// PersonID nas entradas do anime. Não faz nada no dry-run.
+func (r *Runner) linkPeople(ctx context.Context, opts Options, plan animePlan) error {
+	if opts.DryRun || r.People == nil {
+		return nil
+	}
+
+	var people []dto.Person
+	seen := make(map[int]bool)
+	add := func(p dto.Person) {
+		if p.AniListID == 0 || seen[p.AniListID] {
+			return
+		}
+		seen[p.AniListID] = true
+		people = append(people, p)
+	}
+
+	for _, st := range plan.media.Data.Media.Staff.Edges {
+		add(staffPerson(st))
+	}
+	for _, cp := range plan.characters {
+		for _, va := range cp.edge.VoiceActors {
+			add(dto.Person{
+				AniListID:   va.ID,
+				Name:        va.Name.Full,
+				NativeName:  va.Name.Native,
+				Image:       va.Image.Large,
+				SiteUrl:     va.SiteUrl,
+				HomeTown:    va.HomeTown,
+				Gender:      va.Gender,
+				Age:         va.Age,
+				LanguageV2:  va.LanguageV2,
+				DateOfBirth: dto.DateOfBirth(va.DateOfBirth),
+				DateOfDeath: dto.DateOfBirth(va.DateOfDeath),
+			})
+		}
+	}
+
+	ids, err := r.People.UpsertPeople(ctx, people)
+	if err != nil {
+		return fmt.Errorf("erro ao gravar pessoas de %s: %w", plan.anime.ID.Hex(), err)
+	}
+
+	// plan.set e cp.set apontam para os mesmos slices, então basta alterar no lugar
+	for i := range plan.staffs {
+		if id, ok := ids[plan.staffs[i].AniListID]; ok {
+			plan.staffs[i].PersonID = &id
+		}
+	}
+	for _, cp := range plan.characters {
+		for i := range cp.voiceActors {
+			if id, ok := ids[cp.voiceActors[i].AniListID]; ok {
+				cp.voiceActors[i].PersonID = &id
+			}
+		}
+	}
+	return nil
+}
+
+func staffPerson(st logic.StaffEdge) dto.Person {
+	return dto.Person{
+		AniListID:  st.Node.ID,
+		Name:       st.Node.Name.Full,
+		NativeName: st.Node.Name.Native,
+		Image:      st.Node.Image.Large,
+		SiteUrl:    st.Node.SiteUrl,
+		HomeTown:   st.Node.HomeTown,
+		Gender:     st.Node.Gender,
+		Age:        st.Node.Age,
+	}
+}
+
+// PersonRoles mostra todos os papéis de uma pessoa no catálogo. opts.Args[0]
+// é o AniList ID da pessoa ou o _id dela em people.
+func PersonRoles(ctx context.Context, r *Runner, opts Options) error {
+	if r.People == nil {
+		return fmt.Errorf("repositório de pessoas não configurado")
+	}
+	if len(opts.Args) != 1 {
+		return fmt.Errorf("uso: person-roles <aniListId | _id>")
+	}
+
+	var (
+		person *dto.Person
+		err    error
+	)
+	if oid, perr := primitive.ObjectIDFromHex(opts.Args[0]); perr == nil {
+		person, err = r.People.FindPerson(ctx, oid)
+	} else if aniListID, perr := strconv.Atoi(opts.Args[0]); perr == nil {
+		person, err = r.People.FindPersonByAniListID(ctx, aniListID)
+	} else {
+		return fmt.Errorf("id inválido %q", opts.Args[0])
+	}
+	if err != nil {
+		return fmt.Errorf("pessoa %s não encontrada: %w", opts.Args[0], err)
+	}
+
+	roles, err := r.Animes.FindPersonRoles(ctx, person.ID)
+	if err != nil {
+		return fmt.Errorf("falha ao buscar papéis: %w", err)
+	}
+
+	fmt.Printf("%s (AniList %d, %s)\n\n", person.Name, person.AniListID, person.ID.Hex())
+	tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "ANIME\tTIPO\tPAPEL\tPERSONAGEM")
+	for _, role := range roles {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", role.AnimeTitle, role.Kind, role.Role, role.Character)
+	}
+	tw.Flush()
+	fmt.Printf("%d papéis\n", len(roles))
+	return nil
+}
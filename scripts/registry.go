@@ -0,0 +1,184 @@
+package scripts
+
+import (
+	"context"
+	"slices"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// Options são os parâmetros comuns recebidos por todos os scripts via CLI.
+type Options struct {
+	// Script é o nome do subcomando em execução, usado no registro em job_runs.
+	Script   string
+	Filter   bson.M
+	Limit    int
+	PageSize int
+	DryRun   bool
+	// Workers é quantos documentos são processados em paralelo.
+	Workers int
+	// ReportDir é onde os scripts gravam relatórios (ex.: o diff do dry-run).
+	ReportDir string
+	// MatchThreshold é a nota mínima (0 a 1) para aceitar um candidato da AniList.
+	MatchThreshold float64
+	// TitlePreference é a ordem de idiomas (ver dto.TitleLanguages) usada
+	// para escolher o título de exibição; vazio mantém o título atual.
+	TitlePreference []string
+	// TagRank é o rank mínimo (0 a 100) para uma tag da AniList ir para Anime.Tags.
+	TagRank int
+	// Resume é o ID de uma execução em job_runs a ser continuada.
+	Resume string
+	// Args são os argumentos posicionais que sobram depois das flags.
+	Args []string
+}
+
+// Script descreve um subcomando executável pela CLI em cmd/main.go.
+type Script struct {
+	Name        string
+	Description string
+	// Usage descreve os argumentos posicionais, quando o script aceita algum.
+	Usage string
+	// Filter é o filtro Mongo usado quando --filter não é informado.
+	Filter bson.M
+	// Limit é o valor padrão de --limit (0 = sem limite).
+	Limit int
+	// Flags são as flags da CLI que o script usa (ver FlagFilter e as
+	// demais); cmd/main.go só registra essas, além de --log.
+	Flags []string
+	Run   func(ctx context.Context, r *Runner, opts Options) error
+}
+
+// Nomes das flags opcionais da CLI, usados em Script.Flags.
+const (
+	FlagFilter         = "filter"
+	FlagLimit          = "limit"
+	FlagPageSize       = "page-size"
+	FlagDryRun         = "dry-run"
+	FlagReportDir      = "report-dir"
+	FlagWorkers        = "workers"
+	FlagResume         = "resume"
+	FlagMatchThreshold = "match-threshold"
+	FlagTitleLanguage  = "title-language"
+	FlagTagRank        = "tag-rank"
+)
+
+// iterationFlags são as flags de todo script que percorre documentos com eachAnime ou eachManga.
+var iterationFlags = []string{FlagFilter, FlagLimit, FlagPageSize, FlagDryRun, FlagWorkers, FlagResume}
+
+func withFlags(base []string, extra ...string) []string {
+	return append(slices.Clone(base), extra...)
+}
+
+// Scripts retorna todos os scripts registrados, na ordem exibida no --help.
+func Scripts() []Script {
+	return []Script{
+		{
+			Name:        "enrich-anilist",
+			Description: "completa animes com dados da AniList (sinopse, staff, estúdios, personagens)",
+			Filter:      bson.M{"aniListApi": bson.M{"$ne": true}, "aniListNotFound": bson.M{"$ne": true}, "aniListMatch.accepted": bson.M{"$ne": false}},
+			Flags:       withFlags(iterationFlags, FlagReportDir, FlagMatchThreshold, FlagTitleLanguage, FlagTagRank),
+			Run:         UpdateAnimes,
+		},
+		{
+			Name:        "enrich-manga",
+			Description: "completa mangás com dados da AniList e vincula as adaptações em anime",
+			Filter:      bson.M{"aniListApi": bson.M{"$ne": true}, "aniListNotFound": bson.M{"$ne": true}, "aniListMatch.accepted": bson.M{"$ne": false}},
+			Flags:       withFlags(iterationFlags, FlagMatchThreshold, FlagTitleLanguage, FlagTagRank),
+			Run:         UpdateMangas,
+		},
+		{
+			Name:        "fill-type",
+			Description: "preenche o campo type consultando a AniList",
+			Filter:      bson.M{"type": "", "aniListNotFound": bson.M{"$ne": true}, "aniListMatch.accepted": bson.M{"$ne": false}},
+			Flags:       withFlags(iterationFlags, FlagMatchThreshold),
+			Run:         UpdateJustTypeAnimes,
+		},
+		{
+			Name:        "refresh-airing",
+			Description: "atualiza status, episódios, data de término e agenda dos animes em exibição ou anunciados",
+			Filter:      bson.M{"status": bson.M{"$in": bson.A{"RELEASING", "NOT_YET_RELEASED"}}, "aniListId": bson.M{"$gt": 0}, "aniListNotFound": bson.M{"$ne": true}},
+			Flags:       iterationFlags,
+			Run:         RefreshAiring,
+		},
+		{
+			Name:        "gpt-enrich",
+			Description: "completa sinopse, status e personagens usando a OpenAI",
+			Filter:      bson.M{"chatGpt": bson.M{"$ne": true}},
+			Limit:       1,
+			Flags:       iterationFlags,
+			Run:         UpdateAnime,
+		},
+		{
+			Name:        "ingest-season",
+			Description: "insere no catálogo os animes de uma temporada (ou intervalo) da AniList que ainda não existem",
+			Usage:       "<temporada> <ano> [<temporada> <ano>]",
+			Flags:       []string{FlagLimit, FlagDryRun, FlagTitleLanguage},
+			Run:         IngestSeason,
+		},
+		{
+			Name:        "franchise",
+			Description: "agrupa os animes em franquias pelas relações da AniList e calcula a ordem de exibição",
+			Filter:      bson.M{},
+			Flags:       []string{FlagFilter, FlagDryRun, FlagReportDir},
+			Run:         Franchise,
+		},
+		{
+			Name:        "person-roles",
+			Description: "lista os papéis de uma pessoa (staff ou dublador) em todos os animes do catálogo",
+			Usage:       "<aniListId | _id>",
+			Run:         PersonRoles,
+		},
+		{
+			Name:        "studio-animes",
+			Description: "lista os animes do catálogo de um estúdio (AniList ID ou parte do nome)",
+			Usage:       "<aniListId | nome> [main]",
+			Run:         StudioAnimes,
+		},
+		{
+			Name:        "snapshot-stats",
+			Description: "grava uma leitura das estatísticas da AniList de cada anime vinculado, para o movers",
+			Filter:      bson.M{"aniListId": bson.M{"$gt": 0}, "aniListNotFound": bson.M{"$ne": true}},
+			Flags:       iterationFlags,
+			Run:         SnapshotStats,
+		},
+		{
+			Name:        "movers",
+			Description: "mostra os animes que mais subiram entre duas datas em uma métrica da AniList",
+			Usage:       "<de AAAA-MM-DD> <até AAAA-MM-DD> [popularity|favourites|trending|averageScore|meanScore]",
+			Limit:       20,
+			Flags:       []string{FlagLimit},
+			Run:         Movers,
+		},
+		{
+			Name:        "review",
+			Description: "lista e resolve a fila de revisão de matches da AniList",
+			Usage:       "list | show <id> | accept <id> [aniListId] | reject <id>",
+			Filter:      bson.M{},
+			Flags:       []string{FlagLimit, FlagDryRun, FlagReportDir, FlagTitleLanguage, FlagTagRank},
+			Run:         Review,
+		},
+		{
+			Name:        "migrate-bson-keys",
+			Description: "renomeia nos animes as chaves antigas em minúsculas (ex.: anilistapi) para as atuais",
+			Flags:       []string{FlagDryRun},
+			Run:         MigrateBSONKeys,
+		},
+		{
+			Name:        "report",
+			Description: "mostra contagens do estado de enriquecimento da coleção",
+			Filter:      bson.M{},
+			Flags:       []string{FlagFilter},
+			Run:         Report,
+		},
+	}
+}
+
+// Lookup procura um script pelo nome do subcomando.
+func Lookup(name string) (Script, bool) {
+	for _, s := range Scripts() {
+		if s.Name == name {
+			return s, true
+		}
+	}
+	return Script{}, false
+}
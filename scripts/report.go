@@ -0,0 +1,35 @@
+package scripts
+
+import (
+	"context"
+	"fmt"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// Report imprime quantos animes (dentro de opts.Filter) estão em cada estado de enriquecimento.
+func Report(ctx context.Context, r *Runner, opts Options) error {
+	counts := []struct {
+		label  string
+		filter bson.M
+	}{
+		{"total", bson.M{}},
+		{"aniListApi", bson.M{"aniListApi": true}},
+		{"aniListNotFound", bson.M{"aniListNotFound": true}},
+		{"match incerto", bson.M{"aniListMatch.accepted": false}},
+		{"sem aniListApi", bson.M{"aniListApi": bson.M{"$ne": true}}},
+		{"sem type", bson.M{"type": ""}},
+		{"chatGpt", bson.M{"chatGpt": true}},
+		{"chatGptDontFound", bson.M{"chatGptDontFound": true}},
+	}
+
+	for _, c := range counts {
+		filter := bson.M{"$and": bson.A{opts.Filter, c.filter}}
+		n, err := r.Animes.Count(ctx, filter)
+		if err != nil {
+			return fmt.Errorf("falha ao contar %s: %w", c.label, err)
+		}
+		fmt.Printf("%-18s %d\n", c.label, n)
+	}
+	return nil
+}
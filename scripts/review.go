@@ -0,0 +1,218 @@
+package scripts
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/gpt-utils/internal/dto"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Review trata a fila de match_reviews. opts.Args escolhe a ação:
+// list (padrão), show <id>, accept <id> [aniListId] ou reject <id>.
+func Review(ctx context.Context, r *Runner, opts Options) error {
+	if r.Reviews == nil {
+		return fmt.Errorf("repositório de revisões não configurado")
+	}
+
+	action, args := "list", opts.Args
+	if len(args) > 0 {
+		action, args = args[0], args[1:]
+	}
+
+	switch action {
+	case "list":
+		return r.listReviews(ctx, opts)
+	case "show":
+		if len(args) != 1 {
+			return fmt.Errorf("uso: review show <id>")
+		}
+		return r.showReview(ctx, args[0])
+	case "accept":
+		if len(args) < 1 || len(args) > 2 {
+			return fmt.Errorf("uso: review accept <id> [aniListId]")
+		}
+		return r.acceptReview(ctx, opts, args[0], args[1:])
+	case "reject":
+		if len(args) != 1 {
+			return fmt.Errorf("uso: review reject <id>")
+		}
+		return r.rejectReview(ctx, opts, args[0])
+	}
+	return fmt.Errorf("ação desconhecida %q (use list, show, accept ou reject)", action)
+}
+
+func (r *Runner) listReviews(ctx context.Context, opts Options) error {
+	reviews, err := r.Reviews.ListReviews(ctx, dto.ReviewPending, opts.Limit)
+	if err != nil {
+		return fmt.Errorf("falha ao listar revisões: %w", err)
+	}
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "ID\tMOTIVO\tTÍTULO\tMELHOR CANDIDATO")
+	for _, rv := range reviews {
+		best := "-"
+		if len(rv.Candidates) > 0 {
+			c := rv.Candidates[0]
+			best = fmt.Sprintf("%s (%d) %.2f", c.Romaji, c.AniListID, c.Score)
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", rv.ID.Hex(), rv.Reason, rv.Title, best)
+	}
+	tw.Flush()
+	fmt.Printf("%d revisões pendentes\n", len(reviews))
+	return nil
+}
+
+func (r *Runner) showReview(ctx context.Context, id string) error {
+	rv, err := r.findReview(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Revisão %s (%s, %s) do anime %s\n\n", rv.ID.Hex(), rv.Reason, rv.Status, rv.AnimeID.Hex())
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	row := func(label string, ours string, value func(c dto.ReviewCandidate) string) {
+		cols := []string{label, ours}
+		for _, c := range rv.Candidates {
+			cols = append(cols, value(c))
+		}
+		fmt.Fprintln(tw, strings.Join(cols, "\t"))
+	}
+	itoa := func(n int) string {
+		if n == 0 {
+			return "-"
+		}
+		return strconv.Itoa(n)
+	}
+
+	row("", "nosso", func(c dto.ReviewCandidate) string { return fmt.Sprintf("#%d", c.AniListID) })
+	row("título", rv.Title, func(c dto.ReviewCandidate) string { return c.Romaji })
+	row("inglês", "", func(c dto.ReviewCandidate) string { return c.English })
+	row("nativo", "", func(c dto.ReviewCandidate) string { return c.Native })
+	row("sinônimos", strings.Join(rv.Synonyms, ", "), func(c dto.ReviewCandidate) string { return strings.Join(c.Synonyms, ", ") })
+	row("formato", rv.Format, func(c dto.ReviewCandidate) string { return c.Format })
+	row("episódios", itoa(rv.Episodes), func(c dto.ReviewCandidate) string { return itoa(c.Episodes) })
+	row("ano", itoa(rv.StartYear), func(c dto.ReviewCandidate) string { return itoa(c.StartYear) })
+	row("nota", "", func(c dto.ReviewCandidate) string { return fmt.Sprintf("%.2f", c.Score) })
+	tw.Flush()
+
+	if len(rv.Candidates) == 0 {
+		fmt.Println("\nNenhum candidato encontrado; use review accept <id> <aniListId> para informar o ID manualmente.")
+	}
+	return nil
+}
+
+// acceptReview fixa o ID AniList escolhido no anime e roda o enrich-anilist
+// só para ele. Sem aniListId, aceita o candidato de maior nota.
+func (r *Runner) acceptReview(ctx context.Context, opts Options, id string, args []string) error {
+	rv, err := r.findPendingReview(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	var candidate dto.ReviewCandidate
+	switch {
+	case len(args) == 1:
+		aniListID, err := strconv.Atoi(args[0])
+		if err != nil {
+			return fmt.Errorf("aniListId inválido %q", args[0])
+		}
+		candidate.AniListID = aniListID
+		for _, c := range rv.Candidates {
+			if c.AniListID == aniListID {
+				candidate = c
+			}
+		}
+	case len(rv.Candidates) > 0:
+		candidate = rv.Candidates[0]
+	default:
+		return fmt.Errorf("revisão %s não tem candidatos; informe o aniListId", rv.ID.Hex())
+	}
+
+	match := &dto.AniListMatch{
+		AniListID: candidate.AniListID,
+		Title:     candidate.Romaji,
+		Score:     candidate.Score,
+		Accepted:  true,
+		MatchedAt: time.Now(),
+	}
+	_, err = r.updateOne(ctx, opts, bson.M{"_id": rv.AnimeID}, bson.M{"$set": bson.M{
+		"aniListId":       candidate.AniListID,
+		"aniListMatch":    match,
+		"aniListNotFound": false,
+		"aniListApi":      false,
+	}})
+	if err != nil {
+		return fmt.Errorf("erro ao fixar aniListId em %s: %w", rv.AnimeID.Hex(), err)
+	}
+	if opts.DryRun {
+		return nil
+	}
+	if err := r.Reviews.ResolveReview(ctx, rv.ID, dto.ReviewAccepted, candidate.AniListID); err != nil {
+		return fmt.Errorf("erro ao resolver revisão %s: %w", rv.ID.Hex(), err)
+	}
+	fmt.Printf("revisão %s aceita com AniList %d; enriquecendo anime %s\n", rv.ID.Hex(), candidate.AniListID, rv.AnimeID.Hex())
+
+	return UpdateAnimes(ctx, r, Options{
+		Script:          "enrich-anilist",
+		Filter:          bson.M{"_id": rv.AnimeID},
+		Limit:           1,
+		ReportDir:       opts.ReportDir,
+		TagRank:         opts.TagRank,
+		TitlePreference: opts.TitlePreference,
+	})
+}
+
+// rejectReview descarta todos os candidatos e marca o anime como não encontrado.
+func (r *Runner) rejectReview(ctx context.Context, opts Options, id string) error {
+	rv, err := r.findPendingReview(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.updateOne(ctx, opts, bson.M{"_id": rv.AnimeID}, bson.M{"$set": bson.M{
+		"aniListNotFound": true,
+		"aniListApi":      true,
+	}})
+	if err != nil {
+		return fmt.Errorf("erro ao atualizar anime %s: %w", rv.AnimeID.Hex(), err)
+	}
+	if opts.DryRun {
+		return nil
+	}
+	if err := r.Reviews.ResolveReview(ctx, rv.ID, dto.ReviewRejected, 0); err != nil {
+		return fmt.Errorf("erro ao resolver revisão %s: %w", rv.ID.Hex(), err)
+	}
+	fmt.Printf("revisão %s rejeitada\n", rv.ID.Hex())
+	return nil
+}
+
+func (r *Runner) findReview(ctx context.Context, id string) (*dto.MatchReview, error) {
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, fmt.Errorf("id de revisão inválido %q", id)
+	}
+	rv, err := r.Reviews.FindReview(ctx, oid)
+	if err != nil {
+		return nil, fmt.Errorf("revisão %s não encontrada: %w", id, err)
+	}
+	return rv, nil
+}
+
+func (r *Runner) findPendingReview(ctx context.Context, id string) (*dto.MatchReview, error) {
+	rv, err := r.findReview(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if rv.Status != dto.ReviewPending {
+		return nil, fmt.Errorf("revisão %s já foi resolvida (%s)", id, rv.Status)
+	}
+	return rv, nil
+}
@@ -0,0 +1,250 @@
+package scripts
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/gpt-utils/internal/dto"
+	"github.com/gpt-utils/internal/logic"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Config reúne o que cmd/main.go lê do ambiente para montar um Runner.
+type Config struct {
+	DBURI     string
+	Database  string
+	OpenAIKey string
+	FTP       logic.FtpConfig
+	// Adult é a política de conteúdo adulto das consultas à AniList (ANILIST_ADULT).
+	Adult logic.AdultPolicy
+	// AniListURL troca o endpoint GraphQL da AniList (ANILIST_URL), por
+	// exemplo por um servidor fake local; vazio usa o da AniList.
+	AniListURL string
+}
+
+// ConfigFromEnv lê DB_URI, DB_NAME, OPENAI_API_KEY, ANILIST_ADULT, ANILIST_URL e FTP_* do ambiente.
+func ConfigFromEnv() (Config, error) {
+	adult, err := logic.ParseAdultPolicy(os.Getenv("ANILIST_ADULT"))
+	if err != nil {
+		return Config{}, err
+	}
+
+	database := os.Getenv("DB_NAME")
+	if database == "" {
+		database = "animeSearch"
+	}
+	return Config{
+		DBURI:     os.Getenv("DB_URI"),
+		Database:  database,
+		OpenAIKey: os.Getenv("OPENAI_API_KEY"),
+		FTP: logic.FtpConfig{
+			Addr:     os.Getenv("FTP_ADDR"),
+			User:     os.Getenv("FTP_USER"),
+			Password: os.Getenv("FTP_PASSWORD"),
+		},
+		Adult:      adult,
+		AniListURL: os.Getenv("ANILIST_URL"),
+	}, nil
+}
+
+// AnimeRepository é o subconjunto de logic.RepositoryMongo usado pelos scripts.
+type AnimeRepository interface {
+	ListAnimesAfter(ctx context.Context, after primitive.ObjectID, pageSize int, query bson.M) ([]dto.Anime, error)
+	UpdateOne(ctx context.Context, filter interface{}, update interface{}, opts ...*options.UpdateOptions) (int64, error)
+	Count(ctx context.Context, filter bson.M) (int, error)
+	FindIDsByAniListID(ctx context.Context, aniListIDs []int) (map[int]primitive.ObjectID, error)
+	FindPersonRoles(ctx context.Context, personID primitive.ObjectID) ([]dto.PersonRole, error)
+	FindAnimesByStudio(ctx context.Context, studioID primitive.ObjectID, mainOnly bool) ([]dto.Anime, error)
+	ListAnimeRelations(ctx context.Context, query bson.M) ([]dto.Anime, error)
+	InsertOne(ctx context.Context, doc dto.Document) error
+	MigrateLegacyAnimeKeys(ctx context.Context) (int64, error)
+}
+
+// MangaRepository é o subconjunto de logic.RepositoryMongo usado com a coleção mangas.
+type MangaRepository interface {
+	ListMangasAfter(ctx context.Context, after primitive.ObjectID, pageSize int, query bson.M) ([]dto.Manga, error)
+	UpdateOne(ctx context.Context, filter interface{}, update interface{}, opts ...*options.UpdateOptions) (int64, error)
+	FindIDsByAniListID(ctx context.Context, aniListIDs []int) (map[int]primitive.ObjectID, error)
+	UpsertMangaStubs(ctx context.Context, relations []dto.Relation) (map[int]primitive.ObjectID, error)
+}
+
+type AniListAPI interface {
+	FetchAllAnimeCharactersByID(ctx context.Context, id int, perPage int) ([]logic.CharacterEdge, *logic.ResponseAnilist, error)
+	FetchMangaByID(ctx context.Context, id int, perPage int) (*logic.ResponseAnilist, error)
+	FetchJustTypeByID(ctx context.Context, id int) (*logic.ResponseJustType, error)
+	FetchAiringByID(ctx context.Context, id int) (*logic.AiringInfo, error)
+	FetchStatsByID(ctx context.Context, id int) (*logic.MediaStats, error)
+	FetchSeasonPage(ctx context.Context, season dto.AnimeSeason, page, perPage int) ([]logic.SeasonMedia, bool, error)
+	SearchAnimeCandidates(ctx context.Context, search string, perPage int) ([]logic.MediaCandidate, error)
+	SearchMediaCandidates(ctx context.Context, mediaType logic.MediaType, search string, perPage int) ([]logic.MediaCandidate, error)
+}
+
+type JobRunRepository interface {
+	CreateJobRun(ctx context.Context, run *dto.JobRun) error
+	FindJobRun(ctx context.Context, id string) (*dto.JobRun, error)
+	RecordJobOutcome(ctx context.Context, runID string, lastID primitive.ObjectID, outcome dto.JobOutcome) error
+	SetJobRunStatus(ctx context.Context, runID, status, errMsg string) error
+}
+
+type MatchReviewRepository interface {
+	UpsertPendingReview(ctx context.Context, review dto.MatchReview) error
+	ListReviews(ctx context.Context, status string, limit int) ([]dto.MatchReview, error)
+	FindReview(ctx context.Context, id primitive.ObjectID) (*dto.MatchReview, error)
+	ResolveReview(ctx context.Context, id primitive.ObjectID, status string, acceptedID int) error
+}
+
+type PersonRepository interface {
+	UpsertPeople(ctx context.Context, people []dto.Person) (map[int]primitive.ObjectID, error)
+	FindPerson(ctx context.Context, id primitive.ObjectID) (*dto.Person, error)
+	FindPersonByAniListID(ctx context.Context, aniListID int) (*dto.Person, error)
+}
+
+type StudioRepository interface {
+	UpsertStudios(ctx context.Context, studios []dto.StudioDocument) (map[int]primitive.ObjectID, error)
+	FindStudioByAniListID(ctx context.Context, aniListID int) (*dto.StudioDocument, error)
+	FindStudiosByName(ctx context.Context, name string) ([]dto.StudioDocument, error)
+}
+
+type StatsRepository interface {
+	EnsureTimeSeries(ctx context.Context) error
+	InsertSnapshot(ctx context.Context, snapshot dto.StatsSnapshot) error
+	LatestSnapshots(ctx context.Context, at time.Time) ([]dto.StatsSnapshot, error)
+}
+
+type OpenAIAPI interface {
+	CallOpenAI(model, input string) ([]byte, error)
+}
+
+type ImageUploader interface {
+	Upload(uploads []Upload) error
+}
+
+type Upload struct {
+	URL  string
+	Path string
+}
+
+const statsCollection = "anime_stats"
+
+// Runner guarda as dependências dos scripts. É montado uma vez em cmd/main.go;
+// em testes pode ser criado diretamente com implementações fake.
+type Runner struct {
+	Animes  AnimeRepository
+	Mangas  MangaRepository
+	Jobs    JobRunRepository
+	Reviews MatchReviewRepository
+	People  PersonRepository
+	Studios StudioRepository
+	Stats   StatsRepository
+	AniList AniListAPI
+	OpenAI  OpenAIAPI
+	Images  ImageUploader
+	// Adult é a mesma política configurada no client da AniList; com
+	// logic.AdultFlag os animes adultos são gravados com hidden.
+	Adult logic.AdultPolicy
+
+	client *mongo.Client
+	// statsOnce cria a coleção de estatísticas só na primeira gravação (ver recordStats).
+	statsOnce sync.Once
+}
+
+// NewRunner conecta no MongoDB e cria os clients HTTP a partir de cfg.
+func NewRunner(ctx context.Context, cfg Config) (*Runner, error) {
+	if cfg.DBURI == "" {
+		return nil, fmt.Errorf("DB_URI não definido")
+	}
+
+	client, err := logic.Connect(ctx, cfg.DBURI)
+	if err != nil {
+		return nil, fmt.Errorf("falha ao conectar no MongoDB: %w", err)
+	}
+
+	httpClient := &http.Client{Timeout: time.Minute}
+
+	aniList := logic.NewAniListClient(httpClient)
+	aniList.Adult = cfg.Adult
+	if cfg.AniListURL != "" {
+		aniList.URL = cfg.AniListURL
+	}
+
+	db := client.Database(cfg.Database)
+
+	animes := logic.NewQueryAnimeMongo(db.Collection("animes"))
+	people := logic.NewQueryPersonMongo(db.Collection("people"))
+	studios := logic.NewQueryStudioMongo(db.Collection("studios"))
+	mangas := logic.NewQueryMangaMongo(db.Collection("mangas"))
+	// sem o índice único os upserts concorrentes dos workers duplicam documentos;
+	// a falha (ex.: usuário só leitura) não impede os scripts que não gravam
+	for _, repo := range []*logic.RepositoryMongo{animes, people, studios, mangas} {
+		if err := repo.EnsureAniListIDIndex(ctx); err != nil {
+			log.Printf("falha ao criar o índice de aniListId em %s: %v", repo.Collection.Name(), err)
+		}
+	}
+
+	r := &Runner{
+		Animes:  animes,
+		Mangas:  mangas,
+		Jobs:    logic.NewQueryJobRunMongo(db.Collection("job_runs")),
+		Reviews: logic.NewQueryMatchReviewMongo(db.Collection("match_reviews")),
+		People:  people,
+		Studios: studios,
+		Stats:   logic.NewQueryStatsMongo(db.Collection(statsCollection)),
+		AniList: aniList,
+		Images:  &ftpUploader{cfg: cfg.FTP},
+		Adult:   cfg.Adult,
+		client:  client,
+	}
+	if cfg.OpenAIKey != "" {
+		r.OpenAI = logic.NewOpenAIClient(httpClient, cfg.OpenAIKey)
+	}
+	return r, nil
+}
+
+// Close encerra a conexão com o MongoDB, se o Runner abriu uma.
+func (r *Runner) Close(ctx context.Context) error {
+	if r.client == nil {
+		return nil
+	}
+	return r.client.Disconnect(ctx)
+}
+
+// ftpUploader baixa cada imagem para output/ e a envia para o FTP.
+type ftpUploader struct {
+	cfg logic.FtpConfig
+}
+
+func (u *ftpUploader) Upload(uploads []Upload) error {
+	if len(uploads) == 0 {
+		return nil
+	}
+
+	ftpClient, err := logic.NewFtpClient(u.cfg.Addr, u.cfg.User, u.cfg.Password)
+	if err != nil {
+		return err
+	}
+
+	defer ftpClient.Close()
+
+	for _, up := range uploads {
+		if err := logic.DownloadImage(up.URL, fmt.Sprintf("output/%s", up.Path)); err != nil {
+			return err
+		}
+
+		if err := ftpClient.UploadFile(fmt.Sprintf("output/%s", up.Path)); err != nil {
+			return err
+		}
+		log.Printf("imagem %s enviada para o FTP", up.Path)
+
+		time.Sleep(1 * time.Second)
+		os.Remove(fmt.Sprintf("output/%s", up.Path))
+	}
+	return nil
+}
@@ -0,0 +1,399 @@
+package scripts
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/gpt-utils/internal/dto"
+	"github.com/gpt-utils/internal/logic"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// fakeAnimes é um AnimeRepository em memória. Os documentos ficam como o
+// Mongo os grava (slice nil vira null) e UpdateOne aplica $set, $push e
+// $addToSet com as mesmas regras, inclusive o erro de $push num campo null.
+// O filtro de ListAnimesAfter é ignorado.
+type fakeAnimes struct {
+	mu   sync.Mutex
+	docs []bson.M
+}
+
+func (f *fakeAnimes) insert(t *testing.T, anime dto.Anime) primitive.ObjectID {
+	t.Helper()
+	if anime.ID.IsZero() {
+		anime.ID = primitive.NewObjectID()
+	}
+	if err := f.InsertOne(context.Background(), &anime); err != nil {
+		t.Fatal(err)
+	}
+	return anime.ID
+}
+
+// anime devolve o documento decodificado como o repositório real faria.
+func (f *fakeAnimes) anime(t *testing.T, id primitive.ObjectID) dto.Anime {
+	t.Helper()
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	doc := f.find(id)
+	if doc == nil {
+		t.Fatalf("anime %s não existe", id.Hex())
+	}
+	var anime dto.Anime
+	if err := decodeDoc(doc, &anime); err != nil {
+		t.Fatal(err)
+	}
+	return anime
+}
+
+func (f *fakeAnimes) find(id primitive.ObjectID) bson.M {
+	for _, doc := range f.docs {
+		if doc["_id"] == id {
+			return doc
+		}
+	}
+	return nil
+}
+
+func (f *fakeAnimes) InsertOne(ctx context.Context, doc dto.Document) error {
+	m, err := toDoc(doc)
+	if err != nil {
+		return err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.docs = append(f.docs, m)
+	return nil
+}
+
+func (f *fakeAnimes) ListAnimesAfter(ctx context.Context, after primitive.ObjectID, pageSize int, query bson.M) ([]dto.Anime, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var animes []dto.Anime
+	for _, doc := range f.docs {
+		id := doc["_id"].(primitive.ObjectID)
+		if !after.IsZero() && id.Hex() <= after.Hex() {
+			continue
+		}
+		var anime dto.Anime
+		if err := decodeDoc(doc, &anime); err != nil {
+			return nil, err
+		}
+		animes = append(animes, anime)
+		if len(animes) == pageSize {
+			break
+		}
+	}
+	return animes, nil
+}
+
+func (f *fakeAnimes) UpdateOne(ctx context.Context, filter interface{}, update interface{}, opts ...*options.UpdateOptions) (int64, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	doc := f.find(filter.(bson.M)["_id"].(primitive.ObjectID))
+	if doc == nil {
+		return 0, nil
+	}
+
+	var arrayFilter bson.M
+	for _, o := range opts {
+		if o != nil && o.ArrayFilters != nil {
+			arrayFilter = o.ArrayFilters.Filters[0].(bson.M)
+		}
+	}
+
+	for op, fields := range update.(bson.M) {
+		for key, value := range fields.(bson.M) {
+			v, err := toValue(value)
+			if err != nil {
+				return 0, err
+			}
+			switch op {
+			case "$set":
+				if err := setPath(doc, key, v, arrayFilter); err != nil {
+					return 0, err
+				}
+			case "$push", "$addToSet":
+				current, exists := doc[key]
+				arr, ok := current.(bson.A)
+				if exists && !ok {
+					return 0, fmt.Errorf("The field '%s' must be an array but is of type %T", key, current)
+				}
+				values := bson.A{v}
+				if each, ok := v.(bson.M)["$each"]; ok {
+					values = each.(bson.A)
+				}
+				for _, item := range values {
+					if op == "$addToSet" && containsValue(arr, item) {
+						continue
+					}
+					arr = append(arr, item)
+				}
+				doc[key] = arr
+			default:
+				return 0, fmt.Errorf("operador %s não suportado pelo fake", op)
+			}
+		}
+	}
+	return 1, nil
+}
+
+func (f *fakeAnimes) Count(ctx context.Context, filter bson.M) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return len(f.docs), nil
+}
+
+func (f *fakeAnimes) FindIDsByAniListID(ctx context.Context, aniListIDs []int) (map[int]primitive.ObjectID, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	ids := make(map[int]primitive.ObjectID)
+	for _, doc := range f.docs {
+		for _, id := range aniListIDs {
+			if v, ok := doc["aniListId"]; ok && fmt.Sprint(v) == fmt.Sprint(id) {
+				ids[id] = doc["_id"].(primitive.ObjectID)
+			}
+		}
+	}
+	return ids, nil
+}
+
+func (f *fakeAnimes) FindPersonRoles(ctx context.Context, personID primitive.ObjectID) ([]dto.PersonRole, error) {
+	return nil, nil
+}
+
+func (f *fakeAnimes) FindAnimesByStudio(ctx context.Context, studioID primitive.ObjectID, mainOnly bool) ([]dto.Anime, error) {
+	return nil, nil
+}
+
+func (f *fakeAnimes) ListAnimeRelations(ctx context.Context, query bson.M) ([]dto.Anime, error) {
+	return f.ListAnimesAfter(ctx, primitive.NilObjectID, len(f.docs), query)
+}
+
+func (f *fakeAnimes) MigrateLegacyAnimeKeys(ctx context.Context) (int64, error) {
+	return 0, nil
+}
+
+// setPath faz o $set de key em doc. Aceita caminhos com ponto e o
+// operador posicional "$[c]" com um único arrayFilter {"c.<campo>": valor}.
+func setPath(doc bson.M, key string, value interface{}, arrayFilter bson.M) error {
+	if field, sub, ok := strings.Cut(key, ".$[c]."); ok {
+		arr, _ := doc[field].(bson.A)
+		for _, item := range arr {
+			elem := item.(bson.M)
+			for k, want := range arrayFilter {
+				w, err := toValue(want)
+				if err != nil {
+					return err
+				}
+				if fmt.Sprint(elem[strings.TrimPrefix(k, "c.")]) == fmt.Sprint(w) {
+					elem[sub] = value
+				}
+			}
+		}
+		return nil
+	}
+
+	parts := strings.Split(key, ".")
+	for _, p := range parts[:len(parts)-1] {
+		next, ok := doc[p].(bson.M)
+		if !ok {
+			next = bson.M{}
+			doc[p] = next
+		}
+		doc = next
+	}
+	doc[parts[len(parts)-1]] = value
+	return nil
+}
+
+func containsValue(arr bson.A, v interface{}) bool {
+	for _, item := range arr {
+		if fmt.Sprint(item) == fmt.Sprint(v) {
+			return true
+		}
+	}
+	return false
+}
+
+// toDoc e toValue passam o valor pelo codec BSON, para que o fake guarde
+// exatamente o que o driver enviaria ao Mongo.
+func toDoc(v interface{}) (bson.M, error) {
+	data, err := bson.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var m bson.M
+	if err := bson.Unmarshal(data, &m); err != nil {
+		return nil, err
+	}
+	return normalize(m).(bson.M), nil
+}
+
+func toValue(v interface{}) (interface{}, error) {
+	m, err := toDoc(bson.M{"v": v})
+	if err != nil {
+		return nil, err
+	}
+	return m["v"], nil
+}
+
+func decodeDoc(doc bson.M, out interface{}) error {
+	data, err := bson.Marshal(doc)
+	if err != nil {
+		return err
+	}
+	return bson.Unmarshal(data, out)
+}
+
+func normalize(v interface{}) interface{} {
+	switch t := v.(type) {
+	case primitive.D:
+		m := bson.M{}
+		for _, e := range t {
+			m[e.Key] = normalize(e.Value)
+		}
+		return m
+	case bson.M:
+		for k, x := range t {
+			t[k] = normalize(x)
+		}
+		return t
+	case bson.A:
+		for i, x := range t {
+			t[i] = normalize(x)
+		}
+		return t
+	}
+	return v
+}
+
+// fakeMangas é o fakeAnimes da coleção mangas.
+type fakeMangas struct {
+	fakeAnimes
+}
+
+func (f *fakeMangas) insertManga(t *testing.T, manga dto.Manga) primitive.ObjectID {
+	t.Helper()
+	if manga.ID.IsZero() {
+		manga.ID = primitive.NewObjectID()
+	}
+	if err := f.InsertOne(context.Background(), &manga); err != nil {
+		t.Fatal(err)
+	}
+	return manga.ID
+}
+
+func (f *fakeMangas) manga(t *testing.T, id primitive.ObjectID) dto.Manga {
+	t.Helper()
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	doc := f.find(id)
+	if doc == nil {
+		t.Fatalf("mangá %s não existe", id.Hex())
+	}
+	var manga dto.Manga
+	if err := decodeDoc(doc, &manga); err != nil {
+		t.Fatal(err)
+	}
+	return manga
+}
+
+func (f *fakeMangas) ListMangasAfter(ctx context.Context, after primitive.ObjectID, pageSize int, query bson.M) ([]dto.Manga, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var mangas []dto.Manga
+	for _, doc := range f.docs {
+		id := doc["_id"].(primitive.ObjectID)
+		if !after.IsZero() && id.Hex() <= after.Hex() {
+			continue
+		}
+		var manga dto.Manga
+		if err := decodeDoc(doc, &manga); err != nil {
+			return nil, err
+		}
+		mangas = append(mangas, manga)
+		if len(mangas) == pageSize {
+			break
+		}
+	}
+	return mangas, nil
+}
+
+func (f *fakeMangas) UpsertMangaStubs(ctx context.Context, relations []dto.Relation) (map[int]primitive.ObjectID, error) {
+	return map[int]primitive.ObjectID{}, nil
+}
+
+// fakeAniList devolve respostas montadas no teste. Os métodos que o teste
+// não configura caem na interface nil embutida e fazem o teste falhar.
+type fakeAniList struct {
+	AniListAPI
+	media      map[int]*logic.ResponseAnilist
+	characters map[int][]logic.CharacterEdge
+	candidates []logic.MediaCandidate
+	stats      map[int]*logic.MediaStats
+	airing     map[int]*logic.AiringInfo
+}
+
+func (f *fakeAniList) FetchAllAnimeCharactersByID(ctx context.Context, id int, perPage int) ([]logic.CharacterEdge, *logic.ResponseAnilist, error) {
+	resp, ok := f.media[id]
+	if !ok {
+		return nil, nil, logic.ErrMediaNotFound
+	}
+	return f.characters[id], resp, nil
+}
+
+func (f *fakeAniList) FetchMangaByID(ctx context.Context, id int, perPage int) (*logic.ResponseAnilist, error) {
+	resp, ok := f.media[id]
+	if !ok {
+		return nil, logic.ErrMediaNotFound
+	}
+	return resp, nil
+}
+
+func (f *fakeAniList) FetchAiringByID(ctx context.Context, id int) (*logic.AiringInfo, error) {
+	info, ok := f.airing[id]
+	if !ok {
+		return nil, logic.ErrMediaNotFound
+	}
+	return info, nil
+}
+
+func (f *fakeAniList) FetchStatsByID(ctx context.Context, id int) (*logic.MediaStats, error) {
+	stats, ok := f.stats[id]
+	if !ok {
+		return nil, logic.ErrMediaNotFound
+	}
+	return stats, nil
+}
+
+func (f *fakeAniList) SearchAnimeCandidates(ctx context.Context, search string, perPage int) ([]logic.MediaCandidate, error) {
+	return f.candidates, nil
+}
+
+// fakeUploader registra os uploads; com err definido todos falham.
+type fakeUploader struct {
+	mu      sync.Mutex
+	uploads []Upload
+	err     error
+}
+
+func (f *fakeUploader) Upload(uploads []Upload) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.err != nil {
+		return f.err
+	}
+	f.uploads = append(f.uploads, uploads...)
+	return nil
+}
@@ -0,0 +1,197 @@
+package scripts
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gpt-utils/internal/dto"
+	"github.com/gpt-utils/internal/logic"
+	"github.com/gpt-utils/internal/logic/utils"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// seasonOrder é a ordem das temporadas dentro do ano, como na AniList.
+var seasonOrder = []string{"WINTER", "SPRING", "SUMMER", "FALL"}
+
+const seasonPageSize = 50
+
+// IngestSeason cria no catálogo os animes das temporadas informadas em
+// opts.Args (<temporada> <ano> [<temporada> <ano>], intervalo inclusivo).
+// Animes cujo AniList ID já existe são ignorados; os novos entram com
+// aniListApi false para o enrich-anilist completar depois.
+func IngestSeason(ctx context.Context, r *Runner, opts Options) error {
+	seasons, err := parseSeasonRange(opts.Args)
+	if err != nil {
+		return err
+	}
+
+	var inserted, skipped int
+	for _, season := range seasons {
+		for page := 1; ; page++ {
+			media, hasNext, err := r.AniList.FetchSeasonPage(ctx, season, page, seasonPageSize)
+			if err != nil {
+				return fmt.Errorf("falha ao buscar %s %d na AniList: %w", season.Season, season.Year, err)
+			}
+
+			ids := make([]int, 0, len(media))
+			for _, m := range media {
+				ids = append(ids, m.ID)
+			}
+			existing, err := r.Animes.FindIDsByAniListID(ctx, ids)
+			if err != nil {
+				return fmt.Errorf("falha ao consultar animes existentes: %w", err)
+			}
+
+			for _, m := range media {
+				if _, ok := existing[m.ID]; ok {
+					skipped++
+					continue
+				}
+				if opts.Limit > 0 && inserted >= opts.Limit {
+					fmt.Printf("%d animes inseridos, %d já existiam (limite atingido)\n", inserted, skipped)
+					return nil
+				}
+
+				anime := animeFromSeasonMedia(m, opts.TitlePreference)
+				anime.Hidden = r.Adult == logic.AdultFlag && m.IsAdult
+				if opts.DryRun {
+					fmt.Printf("[dry-run] inserir %q (AniList %d, %s %d)\n", anime.Title, m.ID, season.Season, season.Year)
+				} else if err := r.Animes.InsertOne(ctx, &anime); mongo.IsDuplicateKeyError(err) {
+					// inserido por outra execução depois da consulta acima
+					skipped++
+					continue
+				} else if err != nil {
+					return fmt.Errorf("erro ao inserir %q: %w", anime.Title, err)
+				}
+				inserted++
+			}
+
+			if !hasNext {
+				break
+			}
+		}
+	}
+
+	fmt.Printf("%d animes inseridos, %d já existiam\n", inserted, skipped)
+	return nil
+}
+
+// parseSeasonRange interpreta "<temporada> <ano>" ou
+// "<temporada> <ano> <temporada> <ano>" e devolve todas as temporadas do intervalo.
+func parseSeasonRange(args []string) ([]dto.AnimeSeason, error) {
+	if len(args) != 2 && len(args) != 4 {
+		return nil, fmt.Errorf("uso: <temporada> <ano> [<temporada> <ano>]")
+	}
+
+	from, err := parseSeason(args[0], args[1])
+	if err != nil {
+		return nil, err
+	}
+	to := from
+	if len(args) == 4 {
+		if to, err = parseSeason(args[2], args[3]); err != nil {
+			return nil, err
+		}
+	}
+
+	var seasons []dto.AnimeSeason
+	for s := from; seasonIndex(s) <= seasonIndex(to); s = nextSeason(s) {
+		seasons = append(seasons, s)
+	}
+	if len(seasons) == 0 {
+		return nil, fmt.Errorf("intervalo vazio: %s %d é depois de %s %d", from.Season, from.Year, to.Season, to.Year)
+	}
+	return seasons, nil
+}
+
+func parseSeason(season, year string) (dto.AnimeSeason, error) {
+	season = strings.ToUpper(season)
+	found := false
+	for _, s := range seasonOrder {
+		if s == season {
+			found = true
+		}
+	}
+	if !found {
+		return dto.AnimeSeason{}, fmt.Errorf("temporada inválida %q (use %s)", season, strings.Join(seasonOrder, ", "))
+	}
+
+	y, err := strconv.Atoi(year)
+	if err != nil {
+		return dto.AnimeSeason{}, fmt.Errorf("ano inválido %q", year)
+	}
+	return dto.AnimeSeason{Year: y, Season: season}, nil
+}
+
+func seasonIndex(s dto.AnimeSeason) int {
+	for i, name := range seasonOrder {
+		if name == s.Season {
+			return s.Year*len(seasonOrder) + i
+		}
+	}
+	return -1
+}
+
+func nextSeason(s dto.AnimeSeason) dto.AnimeSeason {
+	i := seasonIndex(s) + 1
+	return dto.AnimeSeason{Year: i / len(seasonOrder), Season: seasonOrder[i%len(seasonOrder)]}
+}
+
+// animeFromSeasonMedia monta o documento inicial de um anime da temporada.
+func animeFromSeasonMedia(m logic.SeasonMedia, titlePreference []string) dto.Anime {
+	now := time.Now()
+	synopsis := utils.CleanRichText(m.Description)
+
+	titles := m.Title.Titles()
+	title := titles.Preferred(titlePreference)
+	if title == "" {
+		title = titles.Preferred([]string{"userPreferred", "romaji"})
+	}
+
+	genres := m.Genres
+	if genres == nil {
+		genres = []string{}
+	}
+
+	// as listas começam vazias, e não null, para que o enrich-anilist possa
+	// fazer $push nelas
+	return dto.Anime{
+		ID:                primitive.NewObjectID(),
+		AniListID:         m.ID,
+		MalID:             m.IDMal,
+		Title:             title,
+		Titles:            titles,
+		Status:            m.Status,
+		StartDate:         dto.StartDate(m.StartDate),
+		EndDate:           dto.EndDate(m.EndDate),
+		Type:              string(logic.MediaAnime),
+		Format:            m.Format,
+		Episodes:          m.Episodes,
+		Duration:          m.Duration,
+		Source:            m.Source,
+		CountryOfOrigin:   m.CountryOfOrigin,
+		IsAdult:           m.IsAdult,
+		Genres:            genres,
+		Sources:           []string{},
+		Characters:        []dto.Character{},
+		Tags:              []string{},
+		AniListTags:       []dto.Tag{},
+		StreamingEpisodes: []dto.StreamingEpisode{},
+		ExternalLinks:     []dto.ExternalLink{},
+		Studios:           []dto.Studio{},
+		Staffs:            []dto.Staff{},
+		AiringSchedule:    []dto.AiringEpisode{},
+		Relations:         []dto.Relation{},
+		Synopsis:          synopsis.Text,
+		SynopsisMarkdown:  synopsis.Markdown,
+		SynopsisSpoilers:  synopsis.Spoilers,
+		SynopsisSource:    synopsis.Source,
+		Synonyms:          dto.MergeSynonyms(title, titles.All(), m.Synonyms),
+		CreatedAt:         now,
+		UpdatedAt:         now,
+	}
+}
@@ -0,0 +1,65 @@
+package scripts
+
+import (
+	"context"
+	"testing"
+
+	"github.com/gpt-utils/internal/dto"
+	"github.com/gpt-utils/internal/logic"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// TestSeasonStubAcceptsEnrichment garante que o anime criado pelo
+// ingest-season pode ser completado pelo enrich-anilist: as listas são
+// gravadas vazias, e não null, e os personagens novos entram no documento.
+func TestSeasonStubAcceptsEnrichment(t *testing.T) {
+	var m logic.SeasonMedia
+	m.ID = testAniListID
+	m.Title.Romaji = "Shingeki no Kyojin"
+	m.Format = "TV"
+	m.StartDate.Year = 2013
+	stub := animeFromSeasonMedia(m, nil)
+
+	animes := &fakeAnimes{}
+	id := animes.insert(t, stub)
+	for _, field := range []string{"characters", "staffs", "studios", "tags", "genres", "relations"} {
+		if _, ok := animes.find(id)[field].(bson.A); !ok {
+			t.Errorf("%s gravado como %v, quer uma lista vazia", field, animes.find(id)[field])
+		}
+	}
+
+	r, _, _ := newTestRunner(animes)
+	resp, edges := testMedia()
+	plan := planAnimeUpdate(animes.anime(t, id), edges, resp, defaultTagRank, nil)
+	if err := r.applyAnimePlan(context.Background(), Options{}, plan); err != nil {
+		t.Fatal(err)
+	}
+
+	got := animes.anime(t, id)
+	if len(got.Characters) != 2 || got.Characters[0].Name != "Eren Yeager" || got.Characters[1].Name != "Mikasa Ackerman" {
+		t.Errorf("characters = %+v", got.Characters)
+	}
+	if !got.AniListApi || len(got.Staffs) != 1 || len(got.Studios) != 1 {
+		t.Errorf("aniListApi/staffs/studios = %v/%d/%d", got.AniListApi, len(got.Staffs), len(got.Studios))
+	}
+}
+
+// TestApplyAnimePlanNullCharacters cobre os documentos gravados antes da
+// correção do ingest-season, com characters null.
+func TestApplyAnimePlanNullCharacters(t *testing.T) {
+	animes := &fakeAnimes{}
+	id := animes.insert(t, dto.Anime{AniListID: testAniListID, Title: "Shingeki no Kyojin"})
+	if v, ok := animes.find(id)["characters"]; !ok || v != nil {
+		t.Fatalf("characters = %v, quer null", v)
+	}
+
+	r, _, _ := newTestRunner(animes)
+	resp, edges := testMedia()
+	plan := planAnimeUpdate(animes.anime(t, id), edges, resp, defaultTagRank, nil)
+	if err := r.applyAnimePlan(context.Background(), Options{}, plan); err != nil {
+		t.Fatal(err)
+	}
+	if got := animes.anime(t, id); len(got.Characters) != 2 {
+		t.Errorf("characters = %+v", got.Characters)
+	}
+}
@@ -0,0 +1,153 @@
+package scripts
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/gpt-utils/internal/dto"
+	"github.com/gpt-utils/internal/logic"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// SnapshotStats grava uma leitura das estatísticas da AniList (nota,
+// popularidade, favoritos, trending e rankings) de cada anime já vinculado,
+// para que o movers tenha leituras em datas diferentes para comparar. É
+// para rodar periodicamente, ex.: uma vez por dia.
+func SnapshotStats(ctx context.Context, r *Runner, opts Options) error {
+	if r.Stats == nil {
+		return fmt.Errorf("repositório de estatísticas não configurado")
+	}
+
+	return r.eachAnime(ctx, opts, func(anime dto.Anime) error {
+		stats, err := r.AniList.FetchStatsByID(ctx, anime.AniListID)
+		if errors.Is(err, logic.ErrMediaNotFound) {
+			return r.markNotFound(ctx, opts, anime, bson.M{"aniListNotFound": true})
+		}
+		if err != nil {
+			return fmt.Errorf("falha ao buscar estatísticas de %q: %w", anime.Title, err)
+		}
+		return r.recordStats(ctx, opts, anime, *stats)
+	})
+}
+
+// recordStats grava uma leitura das estatísticas da AniList do anime.
+// Não faz nada no dry-run.
+func (r *Runner) recordStats(ctx context.Context, opts Options, anime dto.Anime, stats logic.MediaStats) error {
+	if opts.DryRun || r.Stats == nil {
+		return nil
+	}
+	// sem a coleção de série temporal os snapshots caem numa coleção comum;
+	// criada aqui, e não no NewRunner, os scripts que não gravam não precisam
+	// de permissão de escrita nem de suporte a séries temporais
+	r.statsOnce.Do(func() {
+		if err := r.Stats.EnsureTimeSeries(ctx); err != nil {
+			log.Printf("falha ao criar a coleção de estatísticas: %v", err)
+		}
+	})
+
+	snapshot := dto.StatsSnapshot{
+		AnimeID:      anime.ID,
+		AniListID:    stats.ID,
+		Title:        anime.Title,
+		TakenAt:      time.Now(),
+		AverageScore: stats.AverageScore,
+		MeanScore:    stats.MeanScore,
+		Popularity:   stats.Popularity,
+		Favourites:   stats.Favourites,
+		Trending:     stats.Trending,
+		Rankings:     []dto.Ranking{},
+	}
+	for _, rk := range stats.Rankings {
+		snapshot.Rankings = append(snapshot.Rankings, dto.Ranking(rk))
+	}
+
+	if err := r.Stats.InsertSnapshot(ctx, snapshot); err != nil {
+		return fmt.Errorf("erro ao gravar estatísticas de %s: %w", anime.ID.Hex(), err)
+	}
+	return nil
+}
+
+// Movers compara a última leitura de cada anime até a data inicial com a
+// última até a data final e lista as maiores altas da métrica escolhida
+// (popularity por padrão). opts.Limit limita o número de linhas.
+func Movers(ctx context.Context, r *Runner, opts Options) error {
+	if r.Stats == nil {
+		return fmt.Errorf("repositório de estatísticas não configurado")
+	}
+	if len(opts.Args) < 2 || len(opts.Args) > 3 {
+		return fmt.Errorf("uso: movers <de AAAA-MM-DD> <até AAAA-MM-DD> [métrica]")
+	}
+
+	from, err := time.ParseInLocation("2006-01-02", opts.Args[0], time.Local)
+	if err != nil {
+		return fmt.Errorf("data inicial inválida %q", opts.Args[0])
+	}
+	to, err := time.ParseInLocation("2006-01-02", opts.Args[1], time.Local)
+	if err != nil {
+		return fmt.Errorf("data final inválida %q", opts.Args[1])
+	}
+	// as datas valem até o fim do dia
+	from, to = from.AddDate(0, 0, 1), to.AddDate(0, 0, 1)
+	if !from.Before(to) {
+		return fmt.Errorf("a data inicial precisa ser anterior à final")
+	}
+
+	metric := "popularity"
+	if len(opts.Args) == 3 {
+		metric = opts.Args[2]
+	}
+	if !slices.Contains(dto.StatsMetrics, metric) {
+		return fmt.Errorf("métrica inválida %q (use %s)", metric, strings.Join(dto.StatsMetrics, ", "))
+	}
+
+	before, err := r.Stats.LatestSnapshots(ctx, from)
+	if err != nil {
+		return fmt.Errorf("falha ao ler estatísticas: %w", err)
+	}
+	after, err := r.Stats.LatestSnapshots(ctx, to)
+	if err != nil {
+		return fmt.Errorf("falha ao ler estatísticas: %w", err)
+	}
+
+	start := make(map[string]dto.StatsSnapshot, len(before))
+	for _, s := range before {
+		start[s.AnimeID.Hex()] = s
+	}
+
+	var movers []dto.StatsMover
+	for _, end := range after {
+		begin, ok := start[end.AnimeID.Hex()]
+		// sem leitura nova no período não há o que comparar
+		if !ok || !end.TakenAt.After(begin.TakenAt) {
+			continue
+		}
+		movers = append(movers, dto.StatsMover{
+			AnimeID: end.AnimeID,
+			Title:   end.Title,
+			From:    begin.Metric(metric),
+			To:      end.Metric(metric),
+			Delta:   end.Metric(metric) - begin.Metric(metric),
+		})
+	}
+	sort.SliceStable(movers, func(i, j int) bool { return movers[i].Delta > movers[j].Delta })
+	if opts.Limit > 0 && len(movers) > opts.Limit {
+		movers = movers[:opts.Limit]
+	}
+
+	fmt.Printf("%s de %s a %s\n\n", metric, opts.Args[0], opts.Args[1])
+	tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "VARIAÇÃO\tANTES\tDEPOIS\tTÍTULO")
+	for _, m := range movers {
+		fmt.Fprintf(tw, "%+d\t%d\t%d\t%s\n", m.Delta, m.From, m.To, m.Title)
+	}
+	tw.Flush()
+	return nil
+}
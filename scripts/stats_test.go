@@ -0,0 +1,79 @@
+package scripts
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gpt-utils/internal/dto"
+	"github.com/gpt-utils/internal/logic"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// fakeStats conta quantas vezes a coleção foi criada e guarda os snapshots.
+type fakeStats struct {
+	mu        sync.Mutex
+	ensured   int
+	snapshots []dto.StatsSnapshot
+}
+
+func (f *fakeStats) EnsureTimeSeries(ctx context.Context) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.ensured++
+	return nil
+}
+
+func (f *fakeStats) InsertSnapshot(ctx context.Context, snapshot dto.StatsSnapshot) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.snapshots = append(f.snapshots, snapshot)
+	return nil
+}
+
+func (f *fakeStats) LatestSnapshots(ctx context.Context, at time.Time) ([]dto.StatsSnapshot, error) {
+	return nil, nil
+}
+
+func TestSnapshotStatsRecordsEveryRun(t *testing.T) {
+	animes := &fakeAnimes{}
+	id := animes.insert(t, dto.Anime{AniListID: testAniListID, Title: "Shingeki no Kyojin"})
+	missing := animes.insert(t, dto.Anime{AniListID: 1, Title: "Removido da AniList"})
+
+	stats := &fakeStats{}
+	aniList := &fakeAniList{stats: map[int]*logic.MediaStats{
+		testAniListID: {ID: testAniListID, Popularity: 900000, Rankings: []logic.MediaRanking{{Rank: 1, Type: "POPULAR", AllTime: true}}},
+	}}
+	r := &Runner{Animes: animes, AniList: aniList, Stats: stats}
+	opts := Options{Script: "snapshot-stats", Filter: bson.M{}}
+
+	if err := SnapshotStats(context.Background(), r, Options{Script: "snapshot-stats", Filter: bson.M{}, DryRun: true}); err != nil {
+		t.Fatal(err)
+	}
+	if stats.ensured != 0 || len(stats.snapshots) != 0 {
+		t.Fatalf("dry-run gravou: coleção criada %d vezes, %d snapshots", stats.ensured, len(stats.snapshots))
+	}
+
+	// cada execução é uma nova leitura do mesmo anime
+	for i := 0; i < 2; i++ {
+		if err := SnapshotStats(context.Background(), r, opts); err != nil {
+			t.Fatal(err)
+		}
+		aniList.stats[testAniListID].Popularity += 1000
+	}
+
+	if stats.ensured != 1 || len(stats.snapshots) != 2 {
+		t.Fatalf("coleção criada %d vezes, %d snapshots; quer 1 e 2", stats.ensured, len(stats.snapshots))
+	}
+	first, second := stats.snapshots[0], stats.snapshots[1]
+	if first.AnimeID != id || first.Popularity != 900000 || second.Popularity != 901000 {
+		t.Errorf("snapshots = %+v", stats.snapshots)
+	}
+	if len(first.Rankings) != 1 || first.Rankings[0].Type != "POPULAR" {
+		t.Errorf("rankings = %+v", first.Rankings)
+	}
+	if !animes.anime(t, missing).AniListNotFound {
+		t.Error("anime sem resposta da AniList não foi marcado com aniListNotFound")
+	}
+}
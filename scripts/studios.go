@@ -0,0 +1,117 @@
+package scripts
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"text/tabwriter"
+
+	"github.com/gpt-utils/internal/dto"
+)
+
+// mainStudio escolhe o estúdio de animação principal: o primeiro marcado
+// como principal pela AniList ou, se nenhum for, o primeiro de animação.
+func mainStudio(studios []dto.Studio) *dto.Studio {
+	for i := range studios {
+		if studios[i].IsMain && studios[i].IsAnimationStudio {
+			return &studios[i]
+		}
+	}
+	for i := range studios {
+		if studios[i].IsAnimationStudio {
+			return &studios[i]
+		}
+	}
+	return nil
+}
+
+func mainStudioList(s *dto.Studio) []dto.Studio {
+	if s == nil {
+		return nil
+	}
+	return []dto.Studio{*s}
+}
+
+// linkStudios grava em studios os estúdios do plano e preenche StudioID
+// nas entradas do anime. Não faz nada no dry-run.
+func (r *Runner) linkStudios(ctx context.Context, opts Options, plan animePlan) error {
+	if opts.DryRun || r.Studios == nil || len(plan.studios) == 0 {
+		return nil
+	}
+
+	docs := make([]dto.StudioDocument, 0, len(plan.studios))
+	for _, s := range plan.studios {
+		docs = append(docs, dto.StudioDocument{
+			AniListID:         s.AniListID,
+			Name:              s.Name,
+			SiteUrl:           s.SiteUrl,
+			IsAnimationStudio: s.IsAnimationStudio,
+		})
+	}
+
+	ids, err := r.Studios.UpsertStudios(ctx, docs)
+	if err != nil {
+		return fmt.Errorf("erro ao gravar estúdios de %s: %w", plan.anime.ID.Hex(), err)
+	}
+
+	// plan.set aponta para os mesmos elementos de plan.studios (inclusive mainStudio)
+	for i := range plan.studios {
+		if id, ok := ids[plan.studios[i].AniListID]; ok {
+			plan.studios[i].StudioID = &id
+		}
+	}
+	return nil
+}
+
+// StudioAnimes lista os animes de um estúdio. opts.Args[0] é o AniList ID
+// ou parte do nome; com "main" como segundo argumento, só os animes em que
+// ele é o estúdio principal.
+func StudioAnimes(ctx context.Context, r *Runner, opts Options) error {
+	if r.Studios == nil {
+		return fmt.Errorf("repositório de estúdios não configurado")
+	}
+	if len(opts.Args) < 1 || len(opts.Args) > 2 || (len(opts.Args) == 2 && opts.Args[1] != "main") {
+		return fmt.Errorf("uso: studio-animes <aniListId | nome> [main]")
+	}
+	mainOnly := len(opts.Args) == 2
+
+	var studios []dto.StudioDocument
+	if aniListID, err := strconv.Atoi(opts.Args[0]); err == nil {
+		studio, err := r.Studios.FindStudioByAniListID(ctx, aniListID)
+		if err != nil {
+			return fmt.Errorf("estúdio %d não encontrado: %w", aniListID, err)
+		}
+		studios = append(studios, *studio)
+	} else {
+		found, err := r.Studios.FindStudiosByName(ctx, opts.Args[0])
+		if err != nil {
+			return fmt.Errorf("falha ao buscar estúdio: %w", err)
+		}
+		studios = found
+	}
+	if len(studios) == 0 {
+		return fmt.Errorf("nenhum estúdio com o nome %q", opts.Args[0])
+	}
+
+	for _, studio := range studios {
+		animes, err := r.Animes.FindAnimesByStudio(ctx, studio.ID, mainOnly)
+		if err != nil {
+			return fmt.Errorf("falha ao listar animes de %s: %w", studio.Name, err)
+		}
+
+		fmt.Printf("%s (AniList %d)\n", studio.Name, studio.AniListID)
+		tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(tw, "ANO\tFORMATO\tPRINCIPAL\tTÍTULO")
+		for _, anime := range animes {
+			main := ""
+			if anime.MainStudio != nil && anime.MainStudio.StudioID != nil && *anime.MainStudio.StudioID == studio.ID {
+				main = "sim"
+			}
+			fmt.Fprintf(tw, "%d\t%s\t%s\t%s\n", anime.StartDate.Year, anime.Format, main, anime.Title)
+		}
+		tw.Flush()
+		fmt.Printf("%d animes\n\n", len(animes))
+	}
+	return nil
+}
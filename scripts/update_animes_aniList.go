@@ -2,23 +2,20 @@ package scripts
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"log"
-	"os"
+	"sort"
 	"strings"
-	"time"
+	"sync"
 
 	"github.com/gpt-utils/internal/dto"
 	"github.com/gpt-utils/internal/logic"
 	"github.com/gpt-utils/internal/logic/utils"
-	"github.com/joho/godotenv"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
-	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
-const max = 1000000
-
 func sanitizeFileName(name string) string {
 	invalid := []string{"/", "\\", ":", "*", "?", "\"", "<", ">", "|", ".", "^", "$", "-"}
 	for _, c := range invalid {
@@ -27,290 +24,632 @@ func sanitizeFileName(name string) string {
 	return name
 }
 
-var (
-	client     *mongo.Client
-	collection *mongo.Collection
-	rep        *logic.RepositoryMongo
-)
+// animePlan guarda tudo o que a AniList propõe para um anime antes de
+// qualquer escrita, para que o mesmo cálculo sirva ao update e ao dry-run.
+type animePlan struct {
+	anime      dto.Anime
+	match      *dto.AniListMatch
+	media      *logic.ResponseAnilist
+	staffs     []dto.Staff
+	studios    []dto.Studio
+	relations  []dto.Relation
+	tags       []dto.Tag
+	set        bson.M
+	characters []characterPlan
+	uploads    []Upload
+}
 
-func init() {
+// characterPlan é a alteração de um personagem: update de um existente
+// (matched != nil) ou inclusão de um novo (added).
+type characterPlan struct {
+	matched     *dto.Character
+	set         bson.M
+	added       dto.Character
+	voiceActors []dto.VoiceActor
+	edge        logic.CharacterEdge
+}
 
-	err := godotenv.Load()
-	if err != nil {
-		log.Println("Erro iniciar updateAnime")
-		return
+func UpdateAnimes(ctx context.Context, r *Runner, opts Options) error {
+	var (
+		mu    sync.Mutex
+		diffs []AnimeDiff
+	)
+	addDiff := func(d AnimeDiff) {
+		mu.Lock()
+		defer mu.Unlock()
+		diffs = append(diffs, d)
 	}
 
-	// conecta e inicializa o client só uma vez
-	uri := os.Getenv("DB_URI")
+	err := r.eachAnime(ctx, opts, func(anime dto.Anime) error {
 
-	logic.Connect(uri)
+		notFound := func() error {
+			if opts.DryRun {
+				addDiff(AnimeDiff{ID: anime.ID.Hex(), Title: anime.Title, NotFound: true})
+			}
+			return r.markNotFound(ctx, opts, anime, bson.M{"aniListNotFound": true, "aniListApi": true})
+		}
 
-	client = logic.GetDB()
-	if client == nil {
-		log.Fatal("Mongo client retornou nil em GetDB()")
-	}
+		id, match, err := r.resolveAniListID(ctx, opts, anime)
+		if errors.Is(err, errAnimeNotFound) {
+			if match == nil {
+				return notFound()
+			}
+			// candidato com nota baixa: já ficou salvo em aniListMatch
+			if opts.DryRun {
+				addDiff(AnimeDiff{ID: anime.ID.Hex(), Title: anime.Title, NotFound: true, Match: match})
+			}
+			return err
+		}
+		if err != nil {
+			return err
+		}
 
-	collection = client.Database("animeSearch").Collection("animes")
-	rep = logic.NewQueryAnimeMongo(collection)
-}
+		allEdges, fullResponse, err := r.AniList.FetchAllAnimeCharactersByID(ctx, id, 25)
+		if errors.Is(err, logic.ErrMediaNotFound) {
+			return notFound()
+		}
+		if err != nil {
+			return fmt.Errorf("falha ao buscar %q na AniList: %w", anime.Title, err)
+		}
 
-type Upload struct {
-	URL  string
-	Path string
-}
+		if allEdges == nil && fullResponse.Data.Media.Description == "" {
+			return notFound()
+		}
 
-func UpdateAnimes() {
+		plan := planAnimeUpdate(anime, allEdges, fullResponse, tagRank(opts), opts.TitlePreference)
+		if err := r.linkRelations(ctx, opts, plan.relations); err != nil {
+			return err
+		}
+		if err := r.linkPeople(ctx, opts, plan); err != nil {
+			return err
+		}
+		if err := r.linkStudios(ctx, opts, plan); err != nil {
+			return err
+		}
+		if match != nil {
+			plan.match = match
+			plan.set["aniListMatch"] = match
+		}
+		if r.Adult == logic.AdultFlag {
+			plan.set["hidden"] = fullResponse.Data.Media.IsAdult
+		}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Minute)
-	defer cancel()
+		if opts.DryRun {
+			addDiff(diffAnime(plan))
+			return nil
+		}
 
-	animes, err := rep.ListPageAnime(ctx, 1, max, bson.M{"aniListApi": bson.M{"$ne": true}})
-	if err != nil {
-		log.Fatalf("Falha ao listar Anime: %v", err)
-		return
+		if err := r.applyAnimePlan(ctx, opts, plan); err != nil {
+			return err
+		}
+		if err := r.linkAdaptations(ctx, opts, anime.ID, plan.relations); err != nil {
+			return err
+		}
+		return r.recordStats(ctx, opts, plan.anime, plan.media.Data.Media.Stats())
+	})
+
+	if opts.DryRun {
+		if werr := writeDiffReport(opts.ReportDir, "enrich-anilist", diffs); werr != nil && err == nil {
+			err = werr
+		}
 	}
+	return err
+}
 
-	ctx = context.Background()
-	for _, anime := range animes {
+func planAnimeUpdate(anime dto.Anime, edges []logic.CharacterEdge, fullResponse *logic.ResponseAnilist, minTagRank int, titlePreference []string) animePlan {
+	plan := animePlan{
+		anime:     anime,
+		media:     fullResponse,
+		tags:      mediaTags(fullResponse),
+		staffs:    mediaStaffs(fullResponse, anime.Staffs),
+		relations: mediaRelations(fullResponse),
+	}
 
-		var (
-			uploadsCharacters []struct {
-				URL  string
-				Path string
-			}
-			uploadEpisodes []struct {
-				URL  string
-				Path string
-			}
-		)
+	studioIDs := keptIDs[int]{}
+	for _, st := range anime.Studios {
+		studioIDs.keep(st.AniListID, st.ID)
+	}
+	for _, edge := range fullResponse.Data.Media.Studios.Edges {
+		plan.studios = append(plan.studios, dto.Studio{
+			ID:                studioIDs.id(edge.Node.ID),
+			AniListID:         edge.Node.ID,
+			Name:              edge.Node.Name,
+			SiteUrl:           edge.Node.SiteUrl,
+			IsAnimationStudio: edge.Node.IsAnimationStudio,
+			IsMain:            edge.IsMain,
+		})
+	}
 
-		if len(anime.Title) < 5 {
-			rep.UpdateOne(ctx, bson.M{"_id": anime.ID}, bson.M{"$set": bson.M{"aniListNotFound": true, "aniListApi": true}})
-			continue
+	episodeIDs := keptIDs[string]{}
+	for _, ep := range anime.StreamingEpisodes {
+		episodeIDs.keep(ep.Url, ep.ID)
+	}
+	var docStreamingEpisodes []dto.StreamingEpisode
+	for _, ep := range fullResponse.Data.Media.StreamingEpisodes {
+		plan.uploads = append(plan.uploads, Upload{
+			URL:  ep.Thumbnail,
+			Path: fmt.Sprintf("%s.jpg", sanitizeFileName(ep.Title)),
+		})
+
+		doc := dto.StreamingEpisode{
+			ID:        episodeIDs.id(ep.Url),
+			Site:      ep.Site,
+			PathImage: fmt.Sprintf("%s.jpg", sanitizeFileName(ep.Title)),
+			Title:     ep.Title,
+			Url:       ep.Url,
 		}
+		docStreamingEpisodes = append(docStreamingEpisodes, doc)
+	}
 
-		allEdges, fullResponse, err := logic.FetchAllAnimeCharacters(anime.Title, 25)
-		time.Sleep(3 * time.Second)
+	synopsis := utils.CleanRichText(fullResponse.Data.Media.Description)
+	titles := fullResponse.Data.Media.Title.Titles()
+
+	plan.set = bson.M{
+		"aniListId":         fullResponse.Data.Media.ID,
+		"malId":             fullResponse.Data.Media.IDMal,
+		"synopsis":          synopsis.Text,
+		"synopsisMarkdown":  synopsis.Markdown,
+		"synopsisSpoilers":  synopsis.Spoilers,
+		"synopsisSource":    synopsis.Source,
+		"countryOfOrigin":   fullResponse.Data.Media.CountryOfOrigin,
+		"isAdult":           fullResponse.Data.Media.IsAdult,
+		"episodes":          fullResponse.Data.Media.Episodes,
+		"averageScore":      fullResponse.Data.Media.AverageScore,
+		"type":              fullResponse.Data.Media.Type,
+		"startDate":         fullResponse.Data.Media.StartDate,
+		"endDate":           fullResponse.Data.Media.EndDate,
+		"status":            fullResponse.Data.Media.Status,
+		"source":            fullResponse.Data.Media.Source,
+		"duration":          fullResponse.Data.Media.Duration,
+		"streamingEpisodes": docStreamingEpisodes,
+		"externalLinks":     externalLinks(fullResponse),
+		"trailer":           trailer(fullResponse),
+		"studios":           plan.studios,
+		"mainStudio":        mainStudio(plan.studios),
+		"format":            fullResponse.Data.Media.Format,
+		"aniListApi":        true,
+		"staffs":            plan.staffs,
+		"titles":            titles,
+		"relations":         plan.relations,
+		"nextAiringEpisode": nextAiringEpisode(fullResponse.Data.Media.NextAiringEpisode),
+		"airingSchedule":    airingSchedule(fullResponse.Data.Media.AiringSchedule.Nodes),
+		"genres":            fullResponse.Data.Media.Genres,
+		"aniListTags":       plan.tags,
+		"tags":              surfacedTags(plan.tags, minTagRank),
+	}
 
-		if allEdges == nil && fullResponse.Data.Media.Description == "" {
-			fmt.Println("Not Found")
-			rep.UpdateOne(ctx, bson.M{"_id": anime.ID}, bson.M{"$set": bson.M{"aniListApi": true}})
-			continue
-		}
+	title := anime.Title
+	if preferred := titles.Preferred(titlePreference); preferred != "" {
+		title = preferred
+		plan.set["title"] = title
+	}
+	// o título antigo continua buscável como sinônimo
+	plan.set["synonyms"] = dto.MergeSynonyms(title, []string{anime.Title}, anime.Synonyms, titles.All(), fullResponse.Data.Media.Synonyms)
+
+	planAnimeImages(&plan)
 
-		var staffs []dto.Staff
-		for _, st := range fullResponse.Data.Media.Staff.Edges {
-			staffs = append(staffs, dto.Staff{
-				ID:        primitive.NewObjectID(),
-				Name:      st.Node.Name.Full,
-				SiteUrl:   st.Node.SiteUrl,
-				PathImage: st.Node.Image.Large,
-				HomeTown:  st.Node.HomeTown,
-				Gender:    st.Node.Gender,
-				Age:       st.Node.Age,
+	plan.characters = planCharacters(anime, edges)
+	for _, cp := range plan.characters {
+		if cp.matched == nil || cp.matched.PathImage == "" {
+			plan.uploads = append(plan.uploads, Upload{
+				URL:  cp.edge.Node.Image.Large,
+				Path: characterImagePath(cp.edge),
 			})
 		}
+	}
 
-		/*
-			combined := struct {
-				AllEdges    []logic.CharacterEdge
-				FullReponse *logic.ResponseAnilist
-			}{
-				allEdges,
-				fullResponse,
-			}
+	return plan
+}
 
-			data, err := json.Marshal(combined)
-			if err != nil {
-				panic(err)
-			}
+func mediaTags(resp *logic.ResponseAnilist) []dto.Tag {
+	var tags []dto.Tag
+	for _, t := range resp.Data.Media.Tags {
+		tags = append(tags, dto.Tag{
+			Name:      t.Name,
+			Rank:      t.Rank,
+			IsSpoiler: t.IsMediaSpoiler,
+			Category:  t.Category,
+		})
+	}
+	return tags
+}
 
-			_, err = utils.SaveJSONToFile(data, "anilist", "output")
+// keptIDs guarda o _id das entradas embutidas já gravadas no documento
+// (staff, estúdios, dubladores, episódios), pela chave que as identifica na
+// AniList, para que uma nova execução não troque os _id já referenciados.
+type keptIDs[K comparable] map[K]primitive.ObjectID
+
+// keep registra o _id de uma entrada existente; entradas antigas, sem a
+// chave, não são reaproveitadas.
+func (k keptIDs[K]) keep(key K, id primitive.ObjectID) {
+	var zero K
+	if key != zero && !id.IsZero() {
+		k[key] = id
+	}
+}
 
-			if err != nil {
-				log.Fatalf("Erro ao salvar arquivo: %v", err)
-			}
-		*/
-
-		var docStreamingEpisodes []dto.StreamingEpisode
-		for _, ep := range fullResponse.Data.Media.StreamingEpisodes {
-			uploadEpisodes = append(uploadEpisodes, struct {
-				URL  string
-				Path string
-			}{
-				URL:  ep.Thumbnail,
-				Path: fmt.Sprintf("%s.jpg", sanitizeFileName(ep.Title)),
-			})
+// id devolve o _id já gravado para key ou um novo.
+func (k keptIDs[K]) id(key K) primitive.ObjectID {
+	if id, ok := k[key]; ok {
+		return id
+	}
+	return primitive.NewObjectID()
+}
 
-			doc := dto.StreamingEpisode{
-				ID:        primitive.NewObjectID(),
-				Site:      ep.Site,
-				PathImage: fmt.Sprintf("%s.jpg", sanitizeFileName(ep.Title)),
-				Title:     ep.Title,
-			}
-			docStreamingEpisodes = append(docStreamingEpisodes, doc)
-		}
-
-		_, err = rep.UpdateOne(
-			ctx, bson.M{"_id": anime.ID},
-			bson.M{"$set": bson.M{
-				"synopsis":          fullResponse.Data.Media.Description,
-				"countryOfOrigin":   fullResponse.Data.Media.CountryOfOrigin,
-				"isAdult":           fullResponse.Data.Media.IsAdult,
-				"episodes":          fullResponse.Data.Media.Episodes,
-				"averageScore":      fullResponse.Data.Media.AverageScore,
-				"type":              fullResponse.Data.Media.Format,
-				"startDate":         fullResponse.Data.Media.StartDate,
-				"endDate":           fullResponse.Data.Media.EndDate,
-				"status":            fullResponse.Data.Media.Status,
-				"source":            fullResponse.Data.Media.Source,
-				"duration":          fullResponse.Data.Media.Duration,
-				"streamingEpisodes": docStreamingEpisodes,
-				"studios":           fullResponse.Data.Media.Studios.Nodes,
-				"format":            fullResponse.Data.Media.Format,
-				"aniListApi":        true,
-				"staffs":            staffs,
-			}})
+// mediaStaffs converte a staff da AniList, que devolve uma edge por papel,
+// em um Staff por pessoa com todos os papéis dela. As pessoas que já estão
+// em existing mantêm o _id.
+func mediaStaffs(resp *logic.ResponseAnilist, existing []dto.Staff) []dto.Staff {
+	ids := keptIDs[int]{}
+	for _, st := range existing {
+		ids.keep(st.AniListID, st.ID)
+	}
 
-		if err != nil {
-			log.Fatalf("Erro ao atualizar anime:%v", err)
+	var staffs []dto.Staff
+	staffIndex := make(map[int]int)
+	for _, st := range resp.Data.Media.Staff.Edges {
+		if i, ok := staffIndex[st.Node.ID]; ok {
+			staffs[i].Roles = append(staffs[i].Roles, st.Role)
 			continue
 		}
+		staffIndex[st.Node.ID] = len(staffs)
+		staffs = append(staffs, dto.Staff{
+			ID:        ids.id(st.Node.ID),
+			AniListID: st.Node.ID,
+			Name:      st.Node.Name.Full,
+			SiteUrl:   st.Node.SiteUrl,
+			PathImage: st.Node.Image.Large,
+			HomeTown:  st.Node.HomeTown,
+			Gender:    st.Node.Gender,
+			Age:       st.Node.Age,
+			Roles:     []string{st.Role},
+		})
+	}
+	return staffs
+}
 
-		updateCharacters(ctx, allEdges, anime, &uploadsCharacters)
-		uploadImages(uploadsCharacters)
-		uploadImages(uploadEpisodes)
+func mediaRelations(resp *logic.ResponseAnilist) []dto.Relation {
+	var relations []dto.Relation
+	for _, edge := range resp.Data.Media.Relations.Edges {
+		relations = append(relations, dto.Relation{
+			Type:      edge.RelationType,
+			AniListID: edge.Node.ID,
+			MediaType: edge.Node.Type,
+			Format:    edge.Node.Format,
+			Title:     edge.Node.Title.Romaji,
+			StartDate: dto.StartDate(edge.Node.StartDate),
+		})
 	}
+	return relations
 }
 
-func updateCharacters(ctx context.Context, edges []logic.CharacterEdge, anime dto.Anime, uploadsCharacters *[]struct {
-	URL  string
-	Path string
-}) {
-	for _, edge := range edges {
+func externalLinks(resp *logic.ResponseAnilist) []dto.ExternalLink {
+	links := make([]dto.ExternalLink, 0, len(resp.Data.Media.ExternalLinks))
+	for _, l := range resp.Data.Media.ExternalLinks {
+		links = append(links, dto.ExternalLink(l))
+	}
+	return links
+}
 
-		var matchedCharacter dto.Character
+func trailer(resp *logic.ResponseAnilist) *dto.Trailer {
+	t := resp.Data.Media.Trailer
+	if t == nil || t.ID == "" {
+		return nil
+	}
+	return &dto.Trailer{ID: t.ID, Site: t.Site, Thumbnail: t.Thumbnail}
+}
 
-		for _, character := range anime.Characters {
-			if utils.CompareFirstWords(character.Name, edge.Node.Name.Full) {
-				matchedCharacter = character
-				break
-			}
+// planAnimeImages agenda o upload da capa e do banner. Como nos personagens,
+// só preenche pathImage/bannerPathImage quando o anime ainda não tem imagem.
+func planAnimeImages(plan *animePlan) {
+	media := plan.media.Data.Media
+
+	cover := media.CoverImage.ExtraLarge
+	if cover == "" {
+		cover = media.CoverImage.Large
+	}
+	if cover != "" && plan.anime.PathImage == "" {
+		path := fmt.Sprintf("cover_%d.jpg", media.ID)
+		plan.uploads = append(plan.uploads, Upload{URL: cover, Path: path})
+		plan.set["pathImage"] = path
+	}
+
+	if media.BannerImage != "" && plan.anime.BannerPathImage == "" {
+		path := fmt.Sprintf("banner_%d.jpg", media.ID)
+		plan.uploads = append(plan.uploads, Upload{URL: media.BannerImage, Path: path})
+		plan.set["bannerPathImage"] = path
+	}
+
+	if media.CoverImage.Color != "" {
+		plan.set["coverColor"] = media.CoverImage.Color
+	}
+}
+
+// defaultTagRank é o rank mínimo padrão para uma tag ir para Anime.Tags.
+const defaultTagRank = 60
+
+func tagRank(opts Options) int {
+	if opts.TagRank <= 0 {
+		return defaultTagRank
+	}
+	return opts.TagRank
+}
+
+// surfacedTags devolve os nomes das tags exibidas na busca: rank de pelo
+// menos minRank e sem spoiler, na ordem da AniList (rank decrescente).
+func surfacedTags(tags []dto.Tag, minRank int) []string {
+	names := []string{}
+	for _, t := range tags {
+		if t.Rank >= minRank && !t.IsSpoiler {
+			names = append(names, t.Name)
 		}
+	}
+	return names
+}
 
-		var voiceActors []dto.VoiceActor
-		for _, va := range edge.VoiceActors {
-			voiceActors = append(voiceActors, dto.VoiceActor{
-				ID:          primitive.NewObjectID(),
-				Name:        va.Name.Full,
-				Image:       va.Image.Large,
-				LanguageV2:  va.LanguageV2,
-				SiteUrl:     va.SiteUrl,
-				HomeTown:    va.HomeTown,
-				Gender:      va.Gender,
-				Age:         va.Age,
-				DateOfBirth: va.DateOfBirth,
-				DateOfDeath: va.DateOfDeath,
-			})
+// linkRelations preenche AnimeID nas relações com animes que já estão no
+// catálogo e MangaID nas relações com mangás. Os mangás que ainda não
+// existem na coleção mangas são criados como stubs para o enrich-manga
+// (fora do dry-run). Altera os elementos de relations no lugar.
+func (r *Runner) linkRelations(ctx context.Context, opts Options, relations []dto.Relation) error {
+	var (
+		ids    []int
+		mangas []dto.Relation
+	)
+	for _, rel := range relations {
+		switch logic.MediaType(rel.MediaType) {
+		case logic.MediaAnime:
+			ids = append(ids, rel.AniListID)
+		case logic.MediaManga:
+			mangas = append(mangas, rel)
 		}
+	}
 
-		if matchedCharacter.Name != "" {
-			_, err := rep.UpdateOne(ctx, bson.M{"characters.name": bson.M{"$regex": matchedCharacter.Name, "$options": "i"}}, bson.M{"$set": bson.M{
-				"characters.$.bio":         edge.Node.Description,
-				"characters.$.link":        edge.Node.SiteURL,
-				"characters.$.age":         edge.Node.Age,
-				"characters.$.dateOfBirth": edge.Node.DateOfBirth,
-				"characters.$.voiceActors": voiceActors,
-				"characters.$.aniListApi":  true,
-			}})
+	found, err := r.Animes.FindIDsByAniListID(ctx, ids)
+	if err != nil {
+		return fmt.Errorf("falha ao buscar animes relacionados: %w", err)
+	}
+	for i := range relations {
+		if id, ok := found[relations[i].AniListID]; ok && logic.MediaType(relations[i].MediaType) == logic.MediaAnime {
+			relations[i].AnimeID = &id
+		}
+	}
 
-			if err != nil {
-				log.Fatalf("update character erro if CompareFirstWords: %v", err)
+	if len(mangas) == 0 || r.Mangas == nil {
+		return nil
+	}
+	var mangaIDs map[int]primitive.ObjectID
+	if opts.DryRun {
+		aniListIDs := make([]int, 0, len(mangas))
+		for _, rel := range mangas {
+			aniListIDs = append(aniListIDs, rel.AniListID)
+		}
+		mangaIDs, err = r.Mangas.FindIDsByAniListID(ctx, aniListIDs)
+	} else {
+		mangaIDs, err = r.Mangas.UpsertMangaStubs(ctx, mangas)
+	}
+	if err != nil {
+		return fmt.Errorf("falha ao gravar mangás relacionados: %w", err)
+	}
+	for i := range relations {
+		if id, ok := mangaIDs[relations[i].AniListID]; ok && logic.MediaType(relations[i].MediaType) == logic.MediaManga {
+			relations[i].MangaID = &id
+		}
+	}
+	return nil
+}
+
+// linkAdaptations acrescenta o anime em animeIds dos mangás relacionados a
+// ele, para que o vínculo exista dos dois lados.
+func (r *Runner) linkAdaptations(ctx context.Context, opts Options, animeID primitive.ObjectID, relations []dto.Relation) error {
+	if r.Mangas == nil {
+		return nil
+	}
+	for _, rel := range relations {
+		if rel.MangaID == nil {
+			continue
+		}
+		_, err := r.updateManga(ctx, opts, bson.M{"_id": *rel.MangaID}, bson.M{"$addToSet": bson.M{"animeIds": animeID}})
+		if err != nil {
+			return fmt.Errorf("erro ao vincular o mangá %s ao anime %s: %w", rel.MangaID.Hex(), animeID.Hex(), err)
+		}
+	}
+	return nil
+}
+
+// planCharacters reconcilia os personagens da AniList com os do anime.
+// Primeiro casa pelo ID AniList já salvo no personagem; os que sobram são
+// casados pelo nome (completo e nativo) com NameSimilarity, do par mais
+// parecido para o menos, sem reaproveitar um personagem já casado.
+func planCharacters(anime dto.Anime, edges []logic.CharacterEdge) []characterPlan {
+	matches := make([]*dto.Character, len(edges))
+	taken := make(map[int]bool)
+
+	byAniListID := make(map[int]int)
+	for i, character := range anime.Characters {
+		if character.AniListID != 0 {
+			byAniListID[character.AniListID] = i
+		}
+	}
+	for e, edge := range edges {
+		if i, ok := byAniListID[edge.Node.ID]; ok {
+			matches[e] = &anime.Characters[i]
+			taken[i] = true
+		}
+	}
+
+	type pair struct {
+		edge, character int
+		score           float64
+	}
+	var pairs []pair
+	for e, edge := range edges {
+		if matches[e] != nil {
+			continue
+		}
+		for i, character := range anime.Characters {
+			if taken[i] || character.AniListID != 0 {
 				continue
 			}
-
-			if matchedCharacter.PathImage == "" {
-
-				*uploadsCharacters = append(*uploadsCharacters, struct {
-					URL  string
-					Path string
-				}{
-					URL:  edge.Node.Image.Large,
-					Path: fmt.Sprintf("%s.jpg", utils.SanitizeFilename(edge.Node.Name.Full, "_")),
-				})
-
-				_, err = rep.UpdateOne(ctx, bson.M{"characters.name": bson.M{"$regex": matchedCharacter.Name, "$options": "i"}}, bson.M{"$set": bson.M{
-					"characters.$.PathImage": fmt.Sprintf("%s.jpg", utils.SanitizeFilename(edge.Node.Name.Full, "_")),
-				}})
-				if err != nil {
-					log.Fatalf("Falha ao atualizar characters.PathImage|Link: %v", err)
-					continue
-				}
+			if score := characterNameScore(character, edge); score >= characterMatchThreshold {
+				pairs = append(pairs, pair{e, i, score})
 			}
-		} else {
-			_, err := rep.UpdateOne(ctx, bson.M{"_id": anime.ID}, bson.M{
-				"$push": bson.M{
-					"characters": dto.Character{
-						ID:          primitive.NewObjectID(),
-						Name:        edge.Node.Name.Full,
-						Age:         edge.Node.Age,
-						DateOfBirth: edge.Node.DateOfBirth,
-						Bio:         edge.Node.Description,
-						PathImage:   fmt.Sprintf("%s.jpg", utils.SanitizeFilename(edge.Node.Name.Full, "_")),
-						Link:        edge.Node.SiteURL,
-						AniListApi:  true,
-						VoiceActors: voiceActors,
-					},
-				},
-			})
+		}
+	}
+	sort.SliceStable(pairs, func(i, j int) bool { return pairs[i].score > pairs[j].score })
+	for _, p := range pairs {
+		if matches[p.edge] != nil || taken[p.character] {
+			continue
+		}
+		matches[p.edge] = &anime.Characters[p.character]
+		taken[p.character] = true
+	}
 
-			if err != nil {
-				log.Fatalf("Falha ao atualizar characters.PathImage|Link: %v", err)
+	plans := make([]characterPlan, 0, len(edges))
+	for e, edge := range edges {
+		plans = append(plans, planCharacter(edge, matches[e]))
+	}
+	return plans
+}
+
+// characterMatchThreshold é a nota mínima de NameSimilarity para considerar
+// que um personagem salvo é o mesmo da AniList: as mesmas palavras, ou só
+// uma a menos em um dos nomes.
+const characterMatchThreshold = 0.9
+
+func characterNameScore(character dto.Character, edge logic.CharacterEdge) float64 {
+	var best float64
+	for _, ours := range []string{character.Name, character.NativeName} {
+		for _, theirs := range []string{edge.Node.Name.Full, edge.Node.Name.Native} {
+			if ours == "" || theirs == "" {
 				continue
 			}
-
-			*uploadsCharacters = append(*uploadsCharacters, struct {
-				URL  string
-				Path string
-			}{
-				URL:  edge.Node.Image.Large,
-				Path: fmt.Sprintf("%s.jpg", utils.SanitizeFilename(edge.Node.Name.Full, "_")),
-			})
+			if s := utils.NameSimilarity(ours, theirs); s > best {
+				best = s
+			}
 		}
 	}
+	return best
 }
 
-func uploadImages(uploads []struct {
-	URL  string
-	Path string
-}) {
-	addr := os.Getenv("FTP_ADDR")
-	user := os.Getenv("FTP_USER")
-	password := os.Getenv("FTP_PASSWORD")
+func planCharacter(edge logic.CharacterEdge, matched *dto.Character) characterPlan {
+	cp := characterPlan{edge: edge, matched: matched}
+	bio := utils.CleanRichText(edge.Node.Description)
 
-	ftpClient, err := logic.NewFtpClient(addr, user, password)
-	if err != nil {
-		log.Fatal(err)
+	voiceActorIDs := keptIDs[int]{}
+	if matched != nil {
+		for _, va := range matched.VoiceActors {
+			voiceActorIDs.keep(va.AniListID, va.ID)
+		}
+	}
+	for _, va := range edge.VoiceActors {
+		cp.voiceActors = append(cp.voiceActors, dto.VoiceActor{
+			ID:          voiceActorIDs.id(va.ID),
+			AniListID:   va.ID,
+			Name:        va.Name.Full,
+			Image:       va.Image.Large,
+			LanguageV2:  va.LanguageV2,
+			SiteUrl:     va.SiteUrl,
+			HomeTown:    va.HomeTown,
+			Gender:      va.Gender,
+			Age:         va.Age,
+			DateOfBirth: va.DateOfBirth,
+			DateOfDeath: va.DateOfDeath,
+		})
 	}
 
-	defer ftpClient.Close()
+	if cp.matched != nil {
+		cp.set = bson.M{
+			"characters.$[c].aniListId":   edge.Node.ID,
+			"characters.$[c].nativeName":  edge.Node.Name.Native,
+			"characters.$[c].bio":         bio.Text,
+			"characters.$[c].bioMarkdown": bio.Markdown,
+			"characters.$[c].bioSpoilers": bio.Spoilers,
+			"characters.$[c].bioSource":   bio.Source,
+			"characters.$[c].link":        edge.Node.SiteURL,
+			"characters.$[c].age":         edge.Node.Age,
+			"characters.$[c].dateOfBirth": edge.Node.DateOfBirth,
+			"characters.$[c].voiceActors": cp.voiceActors,
+			"characters.$[c].aniListApi":  true,
+		}
+		if cp.matched.PathImage == "" {
+			cp.set["characters.$[c].pathImage"] = characterImagePath(edge)
+		}
+		return cp
+	}
 
-	for _, up := range uploads {
-		err := logic.DownloadImage(up.URL, fmt.Sprintf("output/%s", up.Path))
-		if err != nil {
-			log.Fatal(err)
-			return
+	cp.added = dto.Character{
+		ID:          primitive.NewObjectID(),
+		AniListID:   edge.Node.ID,
+		Name:        edge.Node.Name.Full,
+		NativeName:  edge.Node.Name.Native,
+		Age:         edge.Node.Age,
+		DateOfBirth: edge.Node.DateOfBirth,
+		Bio:         bio.Text,
+		BioMarkdown: bio.Markdown,
+		BioSpoilers: bio.Spoilers,
+		BioSource:   bio.Source,
+		PathImage:   characterImagePath(edge),
+		Link:        edge.Node.SiteURL,
+		AniListApi:  true,
+		VoiceActors: cp.voiceActors,
+	}
+	return cp
+}
+
+// characterArrayFilter identifica o personagem casado dentro do array do
+// anime: pelo ID AniList se já tiver, senão pelo _id ou, por último, pelo nome exato.
+func characterArrayFilter(character *dto.Character) bson.M {
+	switch {
+	case character.AniListID != 0:
+		return bson.M{"c.aniListId": character.AniListID}
+	case !character.ID.IsZero():
+		return bson.M{"c._id": character.ID}
+	}
+	return bson.M{"c.name": character.Name}
+}
+
+func characterImagePath(edge logic.CharacterEdge) string {
+	return fmt.Sprintf("%s.jpg", utils.SanitizeFilename(edge.Node.Name.Full, "_"))
+}
+
+// applyAnimePlan envia as imagens e grava o plano. O $set com aniListApi e
+// os caminhos das imagens vai por último: se um upload ou a gravação de um
+// personagem falhar, o anime continua no filtro padrão e é refeito na
+// próxima execução, quando os personagens já gravados casam pelo nome.
+func (r *Runner) applyAnimePlan(ctx context.Context, opts Options, plan animePlan) error {
+	if err := r.Images.Upload(plan.uploads); err != nil {
+		return fmt.Errorf("falha ao enviar imagens: %w", err)
+	}
+
+	var added []dto.Character
+	for _, cp := range plan.characters {
+		if cp.matched != nil {
+			updateOpts := options.Update().SetArrayFilters(options.ArrayFilters{
+				Filters: []interface{}{characterArrayFilter(cp.matched)},
+			})
+			_, err := r.updateOne(ctx, opts, bson.M{"_id": plan.anime.ID}, bson.M{"$set": cp.set}, updateOpts)
+			if err != nil {
+				return fmt.Errorf("erro ao atualizar personagem %q: %w", cp.matched.Name, err)
+			}
+			continue
 		}
+		added = append(added, cp.added)
+	}
 
-		fmt.Printf("Download %s\n", up.Path)
-		if err := ftpClient.UploadFile(fmt.Sprintf("output/%s", up.Path)); err != nil {
-			log.Fatal(err)
-			return
+	if len(added) > 0 {
+		// $push falha quando characters é null, como nos documentos antigos do ingest-season
+		update := bson.M{"$push": bson.M{"characters": bson.M{"$each": added}}}
+		if plan.anime.Characters == nil {
+			update = bson.M{"$set": bson.M{"characters": added}}
 		}
-		fmt.Println("send to ftp")
+		if _, err := r.updateOne(ctx, opts, bson.M{"_id": plan.anime.ID}, update); err != nil {
+			return fmt.Errorf("falha ao adicionar personagens: %w", err)
+		}
+	}
 
-		time.Sleep(1 * time.Second)
-		os.Remove(fmt.Sprintf("output/%s", up.Path))
+	if _, err := r.updateOne(ctx, opts, bson.M{"_id": plan.anime.ID}, bson.M{"$set": plan.set}); err != nil {
+		return fmt.Errorf("erro ao atualizar anime %s: %w", plan.anime.ID.Hex(), err)
 	}
+	return nil
 }
@@ -0,0 +1,222 @@
+package scripts
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/gpt-utils/internal/dto"
+	"github.com/gpt-utils/internal/logic"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const testAniListID = 16498
+
+// testMedia monta a resposta da AniList de Shingeki no Kyojin usada nos testes.
+func testMedia() (*logic.ResponseAnilist, []logic.CharacterEdge) {
+	resp := &logic.ResponseAnilist{}
+	media := &resp.Data.Media
+	media.ID = testAniListID
+	media.IDMal = 16498
+	media.Type = string(logic.MediaAnime)
+	media.Format = "TV"
+	media.Episodes = 25
+	media.Title.Romaji = "Shingeki no Kyojin"
+	media.Title.English = "Attack on Titan"
+	media.Description = "Humanity lives inside cities.<br><br>\n(Source: Crunchyroll)"
+	media.CoverImage.ExtraLarge = "https://img.anilist.co/cover.jpg"
+	media.CoverImage.Color = "#e4a15d"
+	media.StartDate.Year = 2013
+
+	var director logic.StaffEdge
+	director.Role = "Director"
+	director.Node.ID = 100
+	director.Node.Name.Full = "Tetsurou Araki"
+	storyboard := director
+	storyboard.Role = "Storyboard (eps 1-2)"
+	media.Staff.Edges = []logic.StaffEdge{director, storyboard}
+
+	var studio logic.StudioEdge
+	studio.IsMain = true
+	studio.Node.ID = 858
+	studio.Node.Name = "WIT STUDIO"
+	studio.Node.IsAnimationStudio = true
+	media.Studios.Edges = []logic.StudioEdge{studio}
+
+	var eren, mikasa logic.CharacterEdge
+	eren.Node.ID = 40882
+	eren.Node.Name.Full = "Eren Yeager"
+	eren.Node.Description = "__Height:__ 170 cm\nThe protagonist."
+	mikasa.Node.ID = 40881
+	mikasa.Node.Name.Full = "Mikasa Ackerman"
+	mikasa.Node.Image.Large = "https://img.anilist.co/mikasa.jpg"
+
+	return resp, []logic.CharacterEdge{eren, mikasa}
+}
+
+func testCandidate(id int, romaji string, year int) logic.MediaCandidate {
+	c := logic.MediaCandidate{ID: id, Format: "TV"}
+	c.Title.Romaji = romaji
+	c.StartDate.Year = year
+	return c
+}
+
+func newTestRunner(animes *fakeAnimes) (*Runner, *fakeAniList, *fakeUploader) {
+	resp, edges := testMedia()
+	aniList := &fakeAniList{
+		media:      map[int]*logic.ResponseAnilist{testAniListID: resp},
+		characters: map[int][]logic.CharacterEdge{testAniListID: edges},
+	}
+	uploader := &fakeUploader{}
+	return &Runner{Animes: animes, AniList: aniList, Images: uploader}, aniList, uploader
+}
+
+func TestUpdateAnimesMatchesPlansAndApplies(t *testing.T) {
+	animes := &fakeAnimes{}
+	id := animes.insert(t, dto.Anime{
+		Title:      "Shingeki no Kyojin",
+		Format:     "TV",
+		StartDate:  dto.StartDate{Year: 2013},
+		Characters: []dto.Character{{ID: primitive.NewObjectID(), Name: "Eren Yeager", PathImage: "eren.jpg"}},
+	})
+	r, aniList, uploader := newTestRunner(animes)
+	aniList.candidates = []logic.MediaCandidate{
+		testCandidate(99999, "Shingeki no Bahamut", 2014),
+		testCandidate(testAniListID, "Shingeki no Kyojin", 2013),
+	}
+
+	if err := UpdateAnimes(context.Background(), r, Options{Script: "enrich-anilist", Filter: bson.M{}}); err != nil {
+		t.Fatal(err)
+	}
+	got := animes.anime(t, id)
+
+	// match
+	if got.AniListID != testAniListID {
+		t.Errorf("aniListId = %d, quer %d", got.AniListID, testAniListID)
+	}
+	if got.AniListMatch == nil || !got.AniListMatch.Accepted || got.AniListMatch.AniListID != testAniListID {
+		t.Errorf("aniListMatch = %+v", got.AniListMatch)
+	}
+
+	// plano
+	if !got.AniListApi {
+		t.Error("aniListApi não foi marcado")
+	}
+	if got.Synopsis != "Humanity lives inside cities." || got.SynopsisSource != "Crunchyroll" {
+		t.Errorf("sinopse = %q, fonte = %q", got.Synopsis, got.SynopsisSource)
+	}
+	if got.Type != "ANIME" || got.Format != "TV" || got.Episodes != 25 {
+		t.Errorf("type/format/episodes = %q/%q/%d", got.Type, got.Format, got.Episodes)
+	}
+	if len(got.Staffs) != 1 || !slices.Equal(got.Staffs[0].Roles, []string{"Director", "Storyboard (eps 1-2)"}) {
+		t.Errorf("staffs = %+v", got.Staffs)
+	}
+	if got.MainStudio == nil || got.MainStudio.Name != "WIT STUDIO" {
+		t.Errorf("mainStudio = %+v", got.MainStudio)
+	}
+	if got.PathImage != "cover_16498.jpg" || got.CoverColor != "#e4a15d" {
+		t.Errorf("pathImage/coverColor = %q/%q", got.PathImage, got.CoverColor)
+	}
+
+	// personagens: Eren é atualizado pelo nome, Mikasa é incluída
+	if len(got.Characters) != 2 {
+		t.Fatalf("characters = %+v", got.Characters)
+	}
+	eren, mikasa := got.Characters[0], got.Characters[1]
+	if eren.AniListID != 40882 || eren.Bio != "Height: 170 cm\nThe protagonist." || eren.PathImage != "eren.jpg" {
+		t.Errorf("Eren = %+v", eren)
+	}
+	if mikasa.AniListID != 40881 || mikasa.Name != "Mikasa Ackerman" {
+		t.Errorf("Mikasa = %+v", mikasa)
+	}
+
+	// imagens: capa e a foto da Mikasa; a do Eren já existia
+	var paths []string
+	for _, up := range uploader.uploads {
+		paths = append(paths, up.Path)
+	}
+	slices.Sort(paths)
+	if !slices.Equal(paths, []string{"Mikasa Ackerman.jpg", "cover_16498.jpg"}) {
+		t.Errorf("uploads = %v", paths)
+	}
+}
+
+func TestUpdateAnimesLowConfidenceMatchIsNotApplied(t *testing.T) {
+	animes := &fakeAnimes{}
+	id := animes.insert(t, dto.Anime{Title: "Kyojin", Format: "MOVIE", StartDate: dto.StartDate{Year: 1990}})
+	r, aniList, uploader := newTestRunner(animes)
+	aniList.candidates = []logic.MediaCandidate{testCandidate(testAniListID, "Shingeki no Kyojin", 2013)}
+
+	if err := UpdateAnimes(context.Background(), r, Options{Script: "enrich-anilist", Filter: bson.M{}}); err != nil {
+		t.Fatal(err)
+	}
+	got := animes.anime(t, id)
+
+	if got.AniListMatch == nil || got.AniListMatch.Accepted {
+		t.Fatalf("aniListMatch = %+v, quer um candidato não aceito", got.AniListMatch)
+	}
+	if got.AniListApi || got.AniListID != 0 || len(uploader.uploads) != 0 {
+		t.Errorf("o plano foi aplicado: aniListApi=%v aniListId=%d uploads=%d", got.AniListApi, got.AniListID, len(uploader.uploads))
+	}
+}
+
+func TestUpdateAnimesUploadFailureLeavesAnimePending(t *testing.T) {
+	animes := &fakeAnimes{}
+	id := animes.insert(t, dto.Anime{AniListID: testAniListID, Title: "Shingeki no Kyojin", Characters: []dto.Character{}})
+	r, _, uploader := newTestRunner(animes)
+	uploader.err = errors.New("ftp fora do ar")
+
+	// o erro do anime vai para o log da execução, não para o retorno
+	if err := UpdateAnimes(context.Background(), r, Options{Script: "enrich-anilist", Filter: bson.M{}}); err != nil {
+		t.Fatal(err)
+	}
+	got := animes.anime(t, id)
+	if got.AniListApi || got.PathImage != "" || len(got.Characters) != 0 {
+		t.Errorf("anime gravado apesar do upload falhar: aniListApi=%v pathImage=%q characters=%d", got.AniListApi, got.PathImage, len(got.Characters))
+	}
+
+	// na próxima execução o anime é refeito
+	uploader.err = nil
+	if err := UpdateAnimes(context.Background(), r, Options{Script: "enrich-anilist", Filter: bson.M{}}); err != nil {
+		t.Fatal(err)
+	}
+	if got := animes.anime(t, id); !got.AniListApi || got.PathImage != "cover_16498.jpg" || len(got.Characters) != 2 {
+		t.Errorf("aniListApi=%v pathImage=%q characters=%d", got.AniListApi, got.PathImage, len(got.Characters))
+	}
+}
+
+func TestUpdateAnimesKeepsEmbeddedIDs(t *testing.T) {
+	animes := &fakeAnimes{}
+	id := animes.insert(t, dto.Anime{AniListID: testAniListID, Title: "Shingeki no Kyojin", Characters: []dto.Character{}})
+	r, aniList, _ := newTestRunner(animes)
+	edges := aniList.characters[testAniListID]
+	if err := json.Unmarshal([]byte(`{"voiceActors":[{"id":95014,"name":{"full":"Yuuki Kaji"},"languageV2":"Japanese"}]}`), &edges[0]); err != nil {
+		t.Fatal(err)
+	}
+
+	run := func() dto.Anime {
+		t.Helper()
+		if err := UpdateAnimes(context.Background(), r, Options{Script: "enrich-anilist", Filter: bson.M{}}); err != nil {
+			t.Fatal(err)
+		}
+		return animes.anime(t, id)
+	}
+	first, second := run(), run()
+
+	if len(second.Staffs) != 1 || second.Staffs[0].ID != first.Staffs[0].ID {
+		t.Errorf("staff mudou de _id: %v -> %v", first.Staffs, second.Staffs)
+	}
+	if len(second.Studios) != 1 || second.Studios[0].ID != first.Studios[0].ID {
+		t.Errorf("estúdio mudou de _id: %v -> %v", first.Studios, second.Studios)
+	}
+	eren := second.Characters[0]
+	if len(second.Characters) != 2 || eren.ID != first.Characters[0].ID {
+		t.Fatalf("personagens = %+v", second.Characters)
+	}
+	if len(eren.VoiceActors) != 1 || eren.VoiceActors[0].ID != first.Characters[0].VoiceActors[0].ID {
+		t.Errorf("dublador mudou de _id: %v -> %v", first.Characters[0].VoiceActors, eren.VoiceActors)
+	}
+}
@@ -2,63 +2,42 @@ package scripts
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"log"
-	"os"
-	"time"
 
+	"github.com/gpt-utils/internal/dto"
 	"github.com/gpt-utils/internal/logic"
-	"github.com/joho/godotenv"
 	"go.mongodb.org/mongo-driver/bson"
 )
 
-func init() {
+func UpdateJustTypeAnimes(ctx context.Context, r *Runner, opts Options) error {
+	return r.eachAnime(ctx, opts, func(anime dto.Anime) error {
 
-	err := godotenv.Load()
-	if err != nil {
-		log.Println("Erro iniciar updateAnime")
-		return
-	}
-
-	// conecta e inicializa o client só uma vez
-	uri := os.Getenv("DB_URI")
-
-	logic.Connect(uri)
-
-	client = logic.GetDB()
-	if client == nil {
-		log.Fatal("Mongo client retornou nil em GetDB()")
-	}
-
-	collection = client.Database("animeSearch").Collection("animes")
-	rep = logic.NewQueryAnimeMongo(collection)
-}
-
-func UpdateJustTypeAnimes() {
-
-	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Minute)
-	defer cancel()
-
-	animes, err := rep.ListPageAnime(ctx, 1, max, bson.M{"type": ""})
-	if err != nil {
-		log.Fatalf("Falha ao listar Anime: %v", err)
-		return
-	}
-
-	ctx = context.Background()
-	for _, anime := range animes {
-
-		if len(anime.Title) < 5 {
-			continue
+		id, match, err := r.resolveAniListID(ctx, opts, anime)
+		if errors.Is(err, errAnimeNotFound) && match == nil {
+			return r.markNotFound(ctx, opts, anime, bson.M{"aniListNotFound": true})
+		}
+		if err != nil {
+			return err
 		}
 
-		resp, err := logic.FetchJustType(anime.Title)
+		resp, err := r.AniList.FetchJustTypeByID(ctx, id)
+		if errors.Is(err, logic.ErrMediaNotFound) {
+			return r.markNotFound(ctx, opts, anime, bson.M{"aniListNotFound": true})
+		}
 		if err != nil {
-			log.Fatal(err)
+			return fmt.Errorf("falha ao buscar type de %q: %w", anime.Title, err)
 		}
-		rep.UpdateOne(ctx, bson.M{"_id": anime.ID}, bson.M{"$set": bson.M{"type": resp.Data.Media.Type}})
-		fmt.Printf("update %v with status: %v \n", anime.ID.Hex(), resp.Data.Media.Type)
 
-		time.Sleep(time.Second * 3)
-	}
+		set := bson.M{"type": resp.Data.Media.Type}
+		if match != nil {
+			set["aniListId"] = match.AniListID
+			set["aniListMatch"] = match
+		}
+		if _, err := r.updateOne(ctx, opts, bson.M{"_id": anime.ID}, bson.M{"$set": set}); err != nil {
+			return fmt.Errorf("erro ao atualizar type de %s: %w", anime.ID.Hex(), err)
+		}
+		fmt.Printf("update %v with status: %v \n", anime.ID.Hex(), resp.Data.Media.Type)
+		return nil
+	})
 }
@@ -0,0 +1,233 @@
+package scripts
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/gpt-utils/internal/dto"
+	"github.com/gpt-utils/internal/logic"
+	"github.com/gpt-utils/internal/logic/utils"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// UpdateMangas é o enrich-anilist da coleção mangas: completa cada mangá com
+// os dados da AniList e o vincula às adaptações em anime do catálogo.
+// Personagens não são importados para mangás.
+func UpdateMangas(ctx context.Context, r *Runner, opts Options) error {
+	if r.Mangas == nil {
+		return fmt.Errorf("repositório de mangás não configurado")
+	}
+
+	return r.eachManga(ctx, opts, func(manga dto.Manga) error {
+		notFound := func() error {
+			log.Printf("%s (%s) não encontrado", manga.ID.Hex(), manga.Title)
+			set := bson.M{"aniListNotFound": true, "aniListApi": true}
+			if _, err := r.updateManga(ctx, opts, bson.M{"_id": manga.ID}, bson.M{"$set": set}); err != nil {
+				return fmt.Errorf("erro ao marcar %s como não encontrado: %w", manga.ID.Hex(), err)
+			}
+			return errAnimeNotFound
+		}
+
+		id, match, err := r.resolveMangaAniListID(ctx, opts, manga)
+		if errors.Is(err, errAnimeNotFound) && match == nil {
+			return notFound()
+		}
+		if err != nil {
+			return err
+		}
+
+		fullResponse, err := r.AniList.FetchMangaByID(ctx, id, 25)
+		if errors.Is(err, logic.ErrMediaNotFound) {
+			return notFound()
+		}
+		if err != nil {
+			return fmt.Errorf("falha ao buscar %q na AniList: %w", manga.Title, err)
+		}
+
+		staffs := mediaStaffs(fullResponse, manga.Staffs)
+		relations := mediaRelations(fullResponse)
+		if err := r.linkRelations(ctx, opts, relations); err != nil {
+			return err
+		}
+		if err := r.linkMangaStaff(ctx, opts, manga, fullResponse, staffs); err != nil {
+			return err
+		}
+
+		set, animeIDs, uploads := planMangaUpdate(manga, fullResponse, staffs, relations, tagRank(opts), opts.TitlePreference)
+		if match != nil {
+			set["aniListMatch"] = match
+		}
+		if r.Adult == logic.AdultFlag {
+			set["hidden"] = fullResponse.Data.Media.IsAdult
+		}
+
+		// $addToSet preserva as adaptações que o enrich-anilist vinculou com
+		// linkAdaptations e que não aparecem nas relações deste mangá
+		update := bson.M{"$set": set}
+		if manga.AnimeIDs == nil {
+			set["animeIds"] = animeIDs
+		} else {
+			update["$addToSet"] = bson.M{"animeIds": bson.M{"$each": animeIDs}}
+		}
+
+		// as imagens vão antes: se o upload falhar, o mangá não fica marcado
+		// com aniListApi e com um pathImage quebrado, e é refeito na próxima execução
+		if !opts.DryRun {
+			if err := r.Images.Upload(uploads); err != nil {
+				return fmt.Errorf("falha ao enviar imagens: %w", err)
+			}
+		}
+		if _, err := r.updateManga(ctx, opts, bson.M{"_id": manga.ID}, update); err != nil {
+			return fmt.Errorf("erro ao atualizar mangá %s: %w", manga.ID.Hex(), err)
+		}
+		return nil
+	})
+}
+
+// planMangaUpdate monta o $set do mangá, as adaptações do catálogo a
+// vincular em animeIds e as imagens a enviar.
+func planMangaUpdate(manga dto.Manga, resp *logic.ResponseAnilist, staffs []dto.Staff, relations []dto.Relation, minTagRank int, titlePreference []string) (bson.M, []primitive.ObjectID, []Upload) {
+	media := resp.Data.Media
+	synopsis := utils.CleanRichText(media.Description)
+	titles := media.Title.Titles()
+	tags := mediaTags(resp)
+
+	// as adaptações são as relações com animes que já estão no catálogo
+	animeIDs := []primitive.ObjectID{}
+	for _, rel := range relations {
+		if rel.AnimeID != nil {
+			animeIDs = append(animeIDs, *rel.AnimeID)
+		}
+	}
+
+	set := bson.M{
+		"aniListId":        media.ID,
+		"malId":            media.IDMal,
+		"synopsis":         synopsis.Text,
+		"synopsisMarkdown": synopsis.Markdown,
+		"synopsisSpoilers": synopsis.Spoilers,
+		"synopsisSource":   synopsis.Source,
+		"countryOfOrigin":  media.CountryOfOrigin,
+		"isAdult":          media.IsAdult,
+		"chapters":         media.Chapters,
+		"volumes":          media.Volumes,
+		"averageScore":     media.AverageScore,
+		"format":           media.Format,
+		"startDate":        media.StartDate,
+		"endDate":          media.EndDate,
+		"status":           media.Status,
+		"source":           media.Source,
+		"titles":           titles,
+		"staffs":           staffs,
+		"relations":        relations,
+		"genres":           media.Genres,
+		"aniListTags":      tags,
+		"tags":             surfacedTags(tags, minTagRank),
+		"aniListApi":       true,
+	}
+
+	title := manga.Title
+	if preferred := titles.Preferred(titlePreference); preferred != "" {
+		title = preferred
+		set["title"] = title
+	}
+	set["synonyms"] = dto.MergeSynonyms(title, []string{manga.Title}, manga.Synonyms, titles.All(), media.Synonyms)
+
+	var uploads []Upload
+	cover := media.CoverImage.ExtraLarge
+	if cover == "" {
+		cover = media.CoverImage.Large
+	}
+	if cover != "" && manga.PathImage == "" {
+		path := fmt.Sprintf("manga_cover_%d.jpg", media.ID)
+		uploads = append(uploads, Upload{URL: cover, Path: path})
+		set["pathImage"] = path
+	}
+	if media.CoverImage.Color != "" {
+		set["coverColor"] = media.CoverImage.Color
+	}
+	return set, animeIDs, uploads
+}
+
+// resolveMangaAniListID é o resolveAniListID dos mangás. Sem fila de revisão:
+// um candidato abaixo de opts.MatchThreshold fica só em aniListMatch.
+func (r *Runner) resolveMangaAniListID(ctx context.Context, opts Options, manga dto.Manga) (int, *dto.AniListMatch, error) {
+	if manga.AniListID != 0 {
+		return manga.AniListID, nil, nil
+	}
+
+	// ScoreCandidate compara títulos, ano e formato, que o mangá também tem
+	pseudo := dto.Anime{
+		Title:     manga.Title,
+		Titles:    manga.Titles,
+		Synonyms:  manga.Synonyms,
+		Format:    manga.Format,
+		StartDate: manga.StartDate,
+	}
+
+	var candidates []logic.MediaCandidate
+	for _, search := range pseudo.TitleVariants() {
+		found, err := r.AniList.SearchMediaCandidates(ctx, logic.MediaManga, search, matchCandidates)
+		if err != nil {
+			return 0, nil, fmt.Errorf("falha ao buscar candidatos de %q: %w", search, err)
+		}
+		if len(found) > 0 {
+			candidates = found
+			break
+		}
+	}
+
+	ranked := logic.RankCandidates(pseudo, candidates)
+	if len(ranked) == 0 {
+		return 0, nil, errAnimeNotFound
+	}
+
+	best := ranked[0]
+	match := &dto.AniListMatch{
+		AniListID: best.Media.ID,
+		Title:     best.Media.Title.Romaji,
+		Score:     best.Score,
+		Accepted:  best.Score >= matchThreshold(opts),
+		MatchedAt: time.Now(),
+	}
+	if match.Accepted {
+		return match.AniListID, match, nil
+	}
+
+	if _, err := r.updateManga(ctx, opts, bson.M{"_id": manga.ID}, bson.M{"$set": bson.M{"aniListMatch": match}}); err != nil {
+		return 0, nil, fmt.Errorf("erro ao salvar match de %s: %w", manga.ID.Hex(), err)
+	}
+	return 0, match, fmt.Errorf("%w: melhor candidato %q com nota %.2f", errAnimeNotFound, match.Title, match.Score)
+}
+
+// linkMangaStaff é o linkPeople dos mangás, só com a staff.
+func (r *Runner) linkMangaStaff(ctx context.Context, opts Options, manga dto.Manga, resp *logic.ResponseAnilist, staffs []dto.Staff) error {
+	if opts.DryRun || r.People == nil {
+		return nil
+	}
+
+	var people []dto.Person
+	seen := make(map[int]bool)
+	for _, st := range resp.Data.Media.Staff.Edges {
+		if st.Node.ID == 0 || seen[st.Node.ID] {
+			continue
+		}
+		seen[st.Node.ID] = true
+		people = append(people, staffPerson(st))
+	}
+
+	ids, err := r.People.UpsertPeople(ctx, people)
+	if err != nil {
+		return fmt.Errorf("erro ao gravar pessoas de %s: %w", manga.ID.Hex(), err)
+	}
+	for i := range staffs {
+		if id, ok := ids[staffs[i].AniListID]; ok {
+			staffs[i].PersonID = &id
+		}
+	}
+	return nil
+}
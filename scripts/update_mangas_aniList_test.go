@@ -0,0 +1,51 @@
+package scripts
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/gpt-utils/internal/dto"
+	"github.com/gpt-utils/internal/logic"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+const testMangaAniListID = 53390
+
+func TestUpdateMangasUploadFailureLeavesMangaPending(t *testing.T) {
+	mangas := &fakeMangas{}
+	id := mangas.insertManga(t, dto.Manga{AniListID: testMangaAniListID, Title: "Shingeki no Kyojin"})
+
+	resp, _ := testMedia()
+	resp.Data.Media.ID = testMangaAniListID
+	resp.Data.Media.Type = string(logic.MediaManga)
+	uploader := &fakeUploader{err: errors.New("ftp fora do ar")}
+	r := &Runner{
+		Animes:  &fakeAnimes{},
+		Mangas:  mangas,
+		AniList: &fakeAniList{media: map[int]*logic.ResponseAnilist{testMangaAniListID: resp}},
+		Images:  uploader,
+	}
+	opts := Options{Script: "enrich-manga", Filter: bson.M{}}
+
+	// o erro do mangá vai para o log da execução, não para o retorno
+	if err := UpdateMangas(context.Background(), r, opts); err != nil {
+		t.Fatal(err)
+	}
+	if got := mangas.manga(t, id); got.AniListApi || got.PathImage != "" {
+		t.Errorf("mangá gravado apesar do upload falhar: aniListApi=%v pathImage=%q", got.AniListApi, got.PathImage)
+	}
+
+	// na próxima execução o mangá é refeito
+	uploader.err = nil
+	if err := UpdateMangas(context.Background(), r, opts); err != nil {
+		t.Fatal(err)
+	}
+	got := mangas.manga(t, id)
+	if !got.AniListApi || got.PathImage != "manga_cover_53390.jpg" {
+		t.Errorf("aniListApi=%v pathImage=%q", got.AniListApi, got.PathImage)
+	}
+	if len(uploader.uploads) != 1 || uploader.uploads[0].Path != "manga_cover_53390.jpg" {
+		t.Errorf("uploads = %+v", uploader.uploads)
+	}
+}